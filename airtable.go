@@ -0,0 +1,247 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const (
+	airtableURL = "https://api.airtable.com/v0/"
+
+	// Maximum number of records accepted in a single request
+	maxAirtableRecords = 10
+)
+
+// Push each drop as a record of the drops table, and its cards as records of
+// the cards table linked to it
+type airtableStore struct {
+	Token      string
+	BaseID     string
+	DropsTable string
+	CardsTable string
+
+	// Map from the default field names to the ones used in the tables
+	Fields map[string]string
+}
+
+type airtableRecord struct {
+	ID     string         `json:"id,omitempty"`
+	Fields map[string]any `json:"fields"`
+}
+
+// Load the optional field mapping, a JSON object of default to custom names
+func loadAirtableFields(path string) (map[string]string, error) {
+	fields := map[string]string{}
+	if path == "" {
+		return fields, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &fields)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (store *airtableStore) field(name string) string {
+	custom, found := store.Fields[name]
+	if found {
+		return custom
+	}
+	return name
+}
+
+func (store *airtableStore) SaveCardSet(ctx context.Context, cardSet *CardSet) error {
+	// The records of an unchanged drop are there already
+	if cardSet.unchanged {
+		return nil
+	}
+
+	// Drops are merged on their link, so that an update doesn't add another
+	drops, err := store.write(ctx, store.DropsTable, []airtableRecord{{
+		Fields: map[string]any{
+			store.field("Title"):        cardSet.Title,
+			store.field("Release Date"): cardSet.ReleaseDate,
+			store.field("Link"):         cardSet.Link,
+		},
+	}}, []string{store.field("Link")})
+	if err != nil {
+		return err
+	}
+
+	// Cards are merged on their drop link and position, and the ones past the
+	// end of a drop that shrank are deleted
+	var cards []airtableRecord
+	for i, card := range cardSet.Cards {
+		cards = append(cards, airtableRecord{
+			Fields: map[string]any{
+				store.field("Drop"):     []string{drops[0].ID},
+				store.field("Link"):     cardSet.Link,
+				store.field("Position"): i + 1,
+				store.field("Name"):     card.Name,
+				store.field("Number"):   card.Number,
+				store.field("Count"):    card.Count,
+				store.field("Foil"):     card.Foil,
+				store.field("Etched"):   card.Etched,
+				store.field("Token"):    card.Token,
+			},
+		})
+	}
+
+	for i := 0; i < len(cards); i += maxAirtableRecords {
+		_, err = store.write(ctx, store.CardsTable, cards[i:min(i+maxAirtableRecords, len(cards))], []string{store.field("Link"), store.field("Position")})
+		if err != nil {
+			return err
+		}
+	}
+
+	stale, err := store.list(ctx, store.CardsTable, fmt.Sprintf("AND({%s} = %s, {%s} > %d)",
+		store.field("Link"), airtableString(cardSet.Link), store.field("Position"), len(cards)))
+	if err != nil {
+		return err
+	}
+	for i := 0; i < len(stale); i += maxAirtableRecords {
+		err = store.delete(ctx, store.CardsTable, stale[i:min(i+maxAirtableRecords, len(stale))])
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Quote s as a string of an Airtable formula
+func airtableString(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+func (store *airtableStore) tableURL(table string) string {
+	return airtableURL + url.PathEscape(store.BaseID) + "/" + url.PathEscape(table)
+}
+
+// Create the records, or update the ones having the same values of the
+// mergeOn fields when given
+func (store *airtableStore) write(ctx context.Context, table string, records []airtableRecord, mergeOn []string) ([]airtableRecord, error) {
+	method := http.MethodPost
+	payload := map[string]any{
+		"records":  records,
+		"typecast": true,
+	}
+	if mergeOn != nil {
+		method = http.MethodPatch
+		payload["performUpsert"] = map[string]any{"fieldsToMergeOn": mergeOn}
+	}
+
+	var response struct {
+		Records []airtableRecord `json:"records"`
+	}
+	err := store.request(ctx, method, table, store.tableURL(table), payload, &response)
+	if err != nil {
+		return nil, err
+	}
+	if len(response.Records) != len(records) {
+		return nil, fmt.Errorf("airtable %s: unexpected number of records written", table)
+	}
+
+	return response.Records, nil
+}
+
+// Identifiers of the records matching the formula, across all pages
+func (store *airtableStore) list(ctx context.Context, table, formula string) ([]string, error) {
+	var ids []string
+	offset := ""
+	for {
+		query := url.Values{}
+		query.Set("filterByFormula", formula)
+		// Only the identifiers are needed
+		query.Set("fields[]", store.field("Position"))
+		if offset != "" {
+			query.Set("offset", offset)
+		}
+
+		var response struct {
+			Records []airtableRecord `json:"records"`
+			Offset  string           `json:"offset"`
+		}
+		err := store.request(ctx, http.MethodGet, table, store.tableURL(table)+"?"+query.Encode(), nil, &response)
+		if err != nil {
+			return nil, err
+		}
+		for _, record := range response.Records {
+			ids = append(ids, record.ID)
+		}
+
+		offset = response.Offset
+		if offset == "" {
+			return ids, nil
+		}
+	}
+}
+
+func (store *airtableStore) delete(ctx context.Context, table string, ids []string) error {
+	query := url.Values{}
+	for _, id := range ids {
+		query.Add("records[]", id)
+	}
+	return store.request(ctx, http.MethodDelete, table, store.tableURL(table)+"?"+query.Encode(), nil, nil)
+}
+
+// Send the request with payload encoded as JSON, if any, and decode the
+// response into result, if any
+func (store *airtableStore) request(ctx context.Context, method, table, link string, payload, result any) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, method, link, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+store.Token)
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("airtable %s: %s %s", table, resp.Status, data)
+	}
+
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
+
+func (store *airtableStore) Close() error {
+	return nil
+}
@@ -0,0 +1,59 @@
+package sldownloader
+
+import (
+	"bufio"
+	_ "embed"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+//go:embed aliases.txt
+var defaultAliases string
+
+// Scryfall header titles of the drops whose store title doesn't match, by
+// lowercase store title, as shipped
+var titleAliases = map[string]string{}
+
+func init() {
+	err := parseAliases(strings.NewReader(defaultAliases), titleAliases)
+	if err != nil {
+		panic(err)
+	}
+}
+
+func parseAliases(r io.Reader, aliases map[string]string) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		title, header, found := strings.Cut(line, "=>")
+		title = strings.TrimSpace(title)
+		header = strings.TrimSpace(header)
+		if !found || title == "" || header == "" {
+			return fmt.Errorf("invalid alias %q", line)
+		}
+		aliases[strings.ToLower(title)] = header
+	}
+	return scanner.Err()
+}
+
+// Load the aliases of a user file, taking precedence over the shipped ones
+func loadAliases(path string) (map[string]string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	aliases := map[string]string{}
+	err = parseAliases(file, aliases)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return aliases, nil
+}
@@ -0,0 +1,60 @@
+package sldownloader
+
+import (
+	"slices"
+	"strings"
+)
+
+// Reasons to doubt the number of a card
+const (
+	flagFuzzyName     = "fuzzy_name"
+	flagPositional    = "positional"
+	flagReassigned    = "reassigned_image"
+	flagOutOfSequence = "out_of_sequence"
+	flagLowConfidence = "low_confidence"
+)
+
+// Below this share of numbers in sequence the backfilled ones are flagged
+const backfillConfidenceThreshold = 0.75
+
+func (card *CardData) flag(reason string) {
+	if !slices.Contains(card.flags, reason) {
+		card.flags = append(card.flags, reason)
+	}
+}
+
+// Fill in the exported annotations from what was recorded during the scrape,
+// cards loaded from annotated files keep theirs
+func annotate(cards []CardData) {
+	for i := range cards {
+		if cards[i].source == sourceNone && cards[i].NumberSource != "" {
+			continue
+		}
+		cards[i].NumberSource = cards[i].source.String()
+		cards[i].Flags = cards[i].flags
+	}
+}
+
+// Trailing comment of a txt line, such as "ocr, reassigned_image"
+func cardComment(card CardData) string {
+	if card.NumberSource == "" {
+		return ""
+	}
+	return strings.Join(append([]string{card.NumberSource}, card.Flags...), ", ")
+}
+
+// Read back the annotations of a trailing comment
+func parseCardComment(card *CardData, comment string) {
+	var fields []string
+	for _, field := range strings.Split(comment, ",") {
+		field = strings.TrimSpace(field)
+		if field != "" {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) == 0 {
+		return
+	}
+	card.NumberSource = fields[0]
+	card.Flags = fields[1:]
+}
@@ -0,0 +1,73 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+)
+
+// Kinds of warnings that call for someone to look at the drop
+var attentionKinds = []string{warnUnmatched, warnMissing, warnMismatch, warnBackfill}
+
+// A drop of the run whose numbers need checking, with what is wrong with it
+type attentionDrop struct {
+	Title       string          `json:"title"`
+	Link        string          `json:"link"`
+	Filename    string          `json:"filename,omitempty"`
+	ReleaseDate string          `json:"release_date,omitempty"`
+	Problems    []ScrapeWarning `json:"problems"`
+	// Cards left without a number, and the ones numbered by backfilling
+	Missing    []string `json:"missing,omitempty"`
+	Backfilled []string `json:"backfilled,omitempty"`
+}
+
+// Collect the drops of the results with unmatched headers, missing numbers,
+// count mismatches or backfilled numbers
+func needsAttention(results []*ScrapeResult) []attentionDrop {
+	drops := []attentionDrop{}
+	for _, result := range results {
+		var problems []ScrapeWarning
+		for _, warning := range result.Warnings {
+			if slices.Contains(attentionKinds, warning.Kind) {
+				problems = append(problems, warning)
+			}
+		}
+		if len(problems) == 0 {
+			continue
+		}
+
+		cardSet := result.CardSet
+		drop := attentionDrop{
+			Title:       cardSet.Title,
+			Link:        cardSet.Link,
+			Filename:    cardSet.Filename,
+			ReleaseDate: cardSet.ReleaseDate,
+			Problems:    problems,
+		}
+		for _, card := range cardSet.Cards {
+			switch {
+			case card.Number == "":
+				drop.Missing = append(drop.Missing, card.Name)
+			case card.source == sourceBackfill:
+				drop.Backfilled = append(drop.Backfilled, fmt.Sprintf("%s (%s)", card.Name, card.Number))
+			}
+		}
+		drops = append(drops, drop)
+	}
+	return drops
+}
+
+// Write the drops of the results needing attention as a JSON array
+func writeAttentionReport(path string, results []*ScrapeResult) error {
+	drops := needsAttention(results)
+	data, err := json.MarshalIndent(drops, "", "  ")
+	if err != nil {
+		return err
+	}
+	if len(drops) > 0 {
+		log.Println(len(drops), "drops need attention, see", path)
+	}
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,81 @@
+package sldownloader
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"sync"
+	"time"
+)
+
+// One line of the audit log
+type auditEvent struct {
+	Time   time.Time `json:"time"`
+	Action string    `json:"action"`
+	Target string    `json:"target"`
+	Result string    `json:"result,omitempty"`
+	Error  string    `json:"error,omitempty"`
+}
+
+// Append-only JSONL record of every request and write performed by a run
+type auditLog struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+type auditKey struct{}
+
+// A context recording the actions performed with it to the audit log, nil to
+// discard them
+func withAuditLog(ctx context.Context, audit *auditLog) context.Context {
+	return context.WithValue(ctx, auditKey{}, audit)
+}
+
+// The audit log of the context, nil when there is none
+func auditLogOf(ctx context.Context) *auditLog {
+	audit, _ := ctx.Value(auditKey{}).(*auditLog)
+	return audit
+}
+
+func openAuditLog(path string) (*auditLog, error) {
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return nil, err
+	}
+	return &auditLog{file: file}, nil
+}
+
+// Record the outcome of an action, the result is only kept on success
+func (audit *auditLog) Record(action, target, result string, err error) {
+	if audit == nil {
+		return
+	}
+
+	event := auditEvent{
+		Time:   time.Now().UTC(),
+		Action: action,
+		Target: target,
+		Result: result,
+	}
+	if err != nil {
+		event.Result = ""
+		event.Error = err.Error()
+	}
+
+	data, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+
+	// A single write per line keeps concurrent appends intact
+	audit.mu.Lock()
+	defer audit.mu.Unlock()
+	audit.file.Write(append(data, '\n'))
+}
+
+func (audit *auditLog) Close() error {
+	if audit == nil {
+		return nil
+	}
+	return audit.file.Close()
+}
@@ -0,0 +1,109 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"time"
+)
+
+// When a product was listed in the catalog, as observed across crawls
+type availabilityWindow struct {
+	FirstSeen time.Time `json:"first_seen"`
+	LastSeen  time.Time `json:"last_seen"`
+	// Set when the product is no longer listed, cleared if it comes back
+	GoneAt *time.Time `json:"gone_at,omitempty"`
+}
+
+type availabilityEntry struct {
+	Title string `json:"title"`
+	availabilityWindow
+}
+
+// Availability of every product seen so far, by product id
+type availabilityTracker struct {
+	Path     string
+	Products map[string]*availabilityEntry
+}
+
+func loadAvailability(path string) (*availabilityTracker, error) {
+	tracker := &availabilityTracker{
+		Path:     path,
+		Products: map[string]*availabilityEntry{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return tracker, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &tracker.Products)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return tracker, nil
+}
+
+// Go through the whole catalog of the source, updating when each product was
+// seen and marking the ones that are not listed anymore
+func (tracker *availabilityTracker) Update(source productSource) error {
+	now := time.Now().UTC()
+	seen := map[string]bool{}
+
+	for page := 0; ; page++ {
+		products, err := source.Products(page)
+		if err != nil {
+			return err
+		}
+		if len(products) == 0 {
+			break
+		}
+
+		for _, product := range products {
+			seen[product.ProductID] = true
+
+			entry, found := tracker.Products[product.ProductID]
+			if !found {
+				entry = &availabilityEntry{}
+				entry.FirstSeen = now
+				tracker.Products[product.ProductID] = entry
+			}
+			for _, desc := range product.Descriptions {
+				entry.Title = desc.Title
+				break
+			}
+			entry.LastSeen = now
+			entry.GoneAt = nil
+		}
+	}
+
+	for id, entry := range tracker.Products {
+		if !seen[id] && entry.GoneAt == nil {
+			entry.GoneAt = &now
+		}
+	}
+
+	return nil
+}
+
+func (tracker *availabilityTracker) Window(productID string) *availabilityWindow {
+	entry, found := tracker.Products[productID]
+	if !found {
+		return nil
+	}
+	window := entry.availabilityWindow
+	return &window
+}
+
+func (tracker *availabilityTracker) Save() error {
+	data, err := json.MarshalIndent(tracker.Products, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(tracker.Path, data, 0644)
+}
@@ -0,0 +1,71 @@
+package sldownloader
+
+import (
+	"strconv"
+	"strings"
+)
+
+// The arithmetic sequence of collector numbers that most of the numbers
+// already found agree with, where the card at position i has number Offset+i
+type sequenceFit struct {
+	Offset int
+	// How many numbers agree with the sequence, out of all the usable ones
+	Support int
+	Anchors int
+	// Positions of the numbers that disagree with the sequence
+	Outliers []int
+}
+
+func (fit sequenceFit) Confidence() float64 {
+	if fit.Anchors == 0 {
+		return 0
+	}
+	return float64(fit.Support) / float64(fit.Anchors)
+}
+
+// Numeric part of a collector number, ignoring any face or variant suffix
+func leadingNumber(number string) int {
+	end := strings.IndexFunc(number, func(r rune) bool { return r < '0' || r > '9' })
+	if end >= 0 {
+		number = number[:end]
+	}
+	n, _ := strconv.Atoi(number)
+	return n
+}
+
+// Find the sequence supported by the most numbers of the drop, so that a
+// single misread cannot shift the numbers of every other card
+func fitSequence(cards []CardData) (sequenceFit, bool) {
+	var fit sequenceFit
+
+	votes := map[int]int{}
+	offsets := make([]int, len(cards))
+	for i, card := range cards {
+		// Cards numbered in another set are not part of the sequence
+		n := leadingNumber(card.Number)
+		if card.Set != "" || n <= 0 {
+			continue
+		}
+		offsets[i] = n - i
+		votes[n-i]++
+		fit.Anchors++
+	}
+	if fit.Anchors == 0 {
+		return fit, false
+	}
+
+	for offset, support := range votes {
+		if support > fit.Support || (support == fit.Support && offset < fit.Offset) {
+			fit.Offset = offset
+			fit.Support = support
+		}
+	}
+
+	for i, card := range cards {
+		if card.Set == "" && leadingNumber(card.Number) > 0 && offsets[i] != fit.Offset {
+			fit.Outliers = append(fit.Outliers, i)
+		}
+	}
+
+	return fit, true
+}
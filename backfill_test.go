@@ -0,0 +1,31 @@
+package sldownloader
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFitSequence(t *testing.T) {
+	cards := []CardData{
+		{Name: "A", Number: "101"},
+		{Name: "B"},
+		{Name: "C", Number: "103"},
+		{Name: "D", Number: "1"}, // misread
+		{Name: "E", Number: "105a"},
+		{Name: "Treasure", Number: "3", Set: "TSLD", Token: true},
+	}
+
+	fit, found := fitSequence(cards)
+	if !found {
+		t.Fatal("no sequence found")
+	}
+	want := sequenceFit{Offset: 101, Support: 3, Anchors: 4, Outliers: []int{3}}
+	if !reflect.DeepEqual(fit, want) {
+		t.Errorf("got %+v, want %+v", fit, want)
+	}
+
+	_, found = fitSequence([]CardData{{Name: "A"}, {Name: "B"}})
+	if found {
+		t.Error("sequence found without any number")
+	}
+}
@@ -0,0 +1,83 @@
+package sldownloader
+
+import (
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func loadFixture(b *testing.B, name string) []string {
+	data, err := os.ReadFile("testdata/" + name)
+	if err != nil {
+		b.Fatal(err)
+	}
+	return strings.Split(strings.TrimSpace(string(data)), "\n")
+}
+
+func BenchmarkCleanLine(b *testing.B) {
+	lines := loadFixture(b, "lines.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, line := range lines {
+			cleanLine(line)
+		}
+	}
+}
+
+func BenchmarkCleanTitle(b *testing.B) {
+	titles := loadFixture(b, "titles.txt")
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, title := range titles {
+			cleanTitle(title, defaultFilenamePolicy)
+		}
+	}
+}
+
+// Match every store title against every Scryfall header, as in scrapeProduct
+func BenchmarkTitleMatches(b *testing.B) {
+	titles := loadFixture(b, "titles.txt")
+	headers := loadFixture(b, "headers.txt")
+
+	var cleaned []string
+	for _, title := range titles {
+		_, name := cleanTitle(title, defaultFilenamePolicy)
+		cleaned = append(cleaned, headerTitle(name))
+	}
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, title := range cleaned {
+			for _, header := range headers {
+				if titleMatches(title, header) {
+					break
+				}
+			}
+		}
+	}
+}
+
+func BenchmarkAssignNumbers(b *testing.B) {
+	lines := loadFixture(b, "lines.txt")
+
+	var cards, results []CardData
+	for i, line := range lines {
+		name, num, err := cleanLine(line)
+		if err != nil {
+			b.Fatal(line, err)
+		}
+		cards = append(cards, CardData{Name: name, Count: num})
+		results = append(results, CardData{Name: name, Number: fmt.Sprint(1000 + i)})
+	}
+	// Scryfall order rarely matches the store order
+	slices.Reverse(results)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		assignNumbers(slices.Clone(cards), slices.Clone(results))
+	}
+}
@@ -0,0 +1,90 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const scryfallCollectionURL = "https://api.scryfall.com/cards/collection"
+
+// Scryfall accepts at most this many identifiers per collection request
+const maxCollectionIdentifiers = 75
+
+// Fill in the Cardmarket product ids of the cards of the set, which are not
+// exposed by the Scryfall client, by looking up their collector numbers
+func resolveCardmarketIDs(ctx context.Context, cardSet *CardSet) error {
+	type identifier struct {
+		Set             string `json:"set"`
+		CollectorNumber string `json:"collector_number"`
+	}
+
+	// Multiple cards may share the same printing in different finishes
+	positions := map[string][]int{}
+	var identifiers []identifier
+	for i, card := range cardSet.Cards {
+		if card.Number == "" {
+			continue
+		}
+		// Drop the face suffix we add to the upstream numbers
+		number := baseNumber(card.Number)
+		set := scryfallSetCode(card)
+		key := set + "/" + number
+		if len(positions[key]) == 0 {
+			identifiers = append(identifiers, identifier{Set: set, CollectorNumber: number})
+		}
+		positions[key] = append(positions[key], i)
+	}
+
+	retryClient := newRetryClient()
+
+	for start := 0; start < len(identifiers); start += maxCollectionIdentifiers {
+		end := min(start+maxCollectionIdentifiers, len(identifiers))
+
+		body, err := json.Marshal(map[string]any{"identifiers": identifiers[start:end]})
+		if err != nil {
+			return err
+		}
+		req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodPost, scryfallCollectionURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json")
+
+		resp, err := retryClient.Do(req)
+		if err != nil {
+			return err
+		}
+
+		var collection struct {
+			Data []struct {
+				Set             string `json:"set"`
+				CollectorNumber string `json:"collector_number"`
+				CardmarketID    int    `json:"cardmarket_id"`
+			} `json:"data"`
+			NotFound []identifier `json:"not_found"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxProductsResponseSize)).Decode(&collection)
+		resp.Body.Close()
+		if err != nil {
+			return err
+		}
+
+		for _, card := range collection.Data {
+			for _, i := range positions[card.Set+"/"+card.CollectorNumber] {
+				cardSet.Cards[i].CardmarketID = card.CardmarketID
+			}
+		}
+		for _, missing := range collection.NotFound {
+			log.Println("No Scryfall printing for number", missing.CollectorNumber)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,40 @@
+package sldownloader
+
+import "strings"
+
+// Products that are not regular drops, by a fragment of their title, and the
+// category they are recorded under when included
+var productCategories = []struct {
+	fragment string
+	category string
+}{
+	{"Bundle", "bundle"},
+	{"BUNDLE", "bundle"},
+	{"Deluxe Collection", "bundle"},
+	{"They're Just Like Us but", "bundle"},
+	{"Heads I Win, Tails", "bundle"},
+	{"Festival in a Box", "festival"},
+	{"Countdown Kit", "countdown"},
+	{"30th Anniversary Edition", "anniversary"},
+	{"Transformers TCG", "other-game"},
+	{"DRAGON’S ENDGAME", "other-game"},
+	{"Heroes of the Borderlands", "other-game"},
+	{"Welcome to the Hellfire Club", "other-game"},
+	{"D&D Sapphire Anniversary", "other-game"},
+	{"Japanese", "japanese"},
+	{" JP", "japanese"},
+	{" SP", "special"},
+}
+
+// Return the category of a special product, or an empty string for drops
+func productCategory(title string) string {
+	for _, entry := range productCategories {
+		if strings.Contains(title, entry.fragment) {
+			return entry.category
+		}
+	}
+	if strings.Contains(title, "Secret Lair") && strings.Contains(title, "Deck") {
+		return "deck"
+	}
+	return ""
+}
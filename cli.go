@@ -0,0 +1,628 @@
+package sldownloader
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// Subcommands, selected by the first argument
+var commands = map[string]func(args []string) int{
+	"verify":     runVerify,
+	"diff":       runDiff,
+	"convert":    runConvert,
+	"merge":      runMerge,
+	"stats":      runStats,
+	"ev":         runEV,
+	"search":     runSearch,
+	"lint":       runLint,
+	"regenerate": runRegenerate,
+	"tui":        runTUI,
+	"update":     runUpdate,
+	"export-set": runExportSet,
+	"index":      runIndex,
+	"mirror":     runMirror,
+
+	"retry-pending": runRetryPending,
+	"retry-failed":  runRetryFailed,
+}
+
+// Parse flags appearing anywhere among the positional arguments, which are returned
+func parseInterspersed(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		if fs.NArg() == 0 {
+			break
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+	return positional
+}
+
+// Run the command line tool with the arguments following the program name,
+// returning its exit code
+func Run(args []string) int {
+	if len(args) > 0 {
+		cmd, found := commands[args[0]]
+		if found {
+			return cmd(args[1:])
+		}
+	}
+
+	fs := flag.NewFlagSet("sldownloader", flag.ContinueOnError)
+	pageOpt := fs.Int("page", 0, "Which page to start from")
+	doOCROpt := fs.Bool("ocr", false, "Enable OCR to derive collector numbers")
+	noScryfallOpt := fs.Bool("no-scryfall", false, "Don't match the drops on Scryfall, their numbers come from OCR, which is then enabled, and backfilling only, unvalidated")
+	feedOpt := fs.String("feed", "", "Update an RSS feed (or Atom, if the file ends in .atom) with the scraped drops")
+	dbOpt := fs.String("db", "", "Also store the scraped drops in the database at this URL (postgres://...)")
+	scheduleOpt := fs.String("schedule", "", "Keep running and crawl according to this cron expression")
+	jitterOpt := fs.Duration("jitter", 0, "Delay each scheduled crawl by a random amount up to this duration")
+	smtpOpt := fs.String("smtp", "", "Send email notifications through this SMTP server (host:port)")
+	smtpUserOpt := fs.String("smtp-user", "", "SMTP username, the password is read from SMTP_PASSWORD")
+	mailFromOpt := fs.String("mail-from", "", "Sender address of email notifications")
+	mailToOpt := fs.String("mail-to", "", "Comma-separated recipients of email notifications")
+	ntfyOpt := fs.String("ntfy", "", "Send push notifications to this ntfy topic URL, the token is read from NTFY_TOKEN")
+	pushoverOpt := fs.String("pushover", "", "Send push notifications to this Pushover user key, the app token is read from PUSHOVER_TOKEN")
+	pushEventsOpt := fs.String("push-events", "drop,failure", "Comma-separated events that trigger push notifications (drop, failure)")
+	mqttOpt := fs.String("mqtt", "", "Publish JSON events to this MQTT broker (tcp://host:1883)")
+	mqttTopicOpt := fs.String("mqtt-topic", "sldownloader/drops", "MQTT topic where events are published")
+	mqttUserOpt := fs.String("mqtt-user", "", "MQTT username, the password is read from MQTT_PASSWORD")
+	webhookOpt := fs.String("webhook", "", "POST each exported drop as JSON to this URL, signed with WEBHOOK_SECRET if set")
+	sheetOpt := fs.String("sheet", "", "Append the cards of each drop to the Google Sheet with this ID")
+	sheetRangeOpt := fs.String("sheet-range", "Sheet1", "Sheet (or A1 range) where rows are appended")
+	googleCredsOpt := fs.String("google-credentials", os.Getenv("GOOGLE_APPLICATION_CREDENTIALS"), "Path to the service account key used for Google Sheets")
+	airtableOpt := fs.String("airtable", "", "Push drops and cards to the Airtable base with this ID, the token is read from AIRTABLE_TOKEN")
+	airtableDropsOpt := fs.String("airtable-drops", "Drops", "Airtable table where drops are created")
+	airtableCardsOpt := fs.String("airtable-cards", "Cards", "Airtable table where cards are created")
+	airtableFieldsOpt := fs.String("airtable-fields", "", "JSON file mapping the default Airtable field names to custom ones")
+	manifestOpt := fs.String("manifest", "", "Write the manifest of the files exported by the run to this file")
+	signKeyOpt := fs.String("sign-key", "", "Sign the run manifest with this Ed25519 key, either PEM or unencrypted minisign, writing a detached signature")
+	releaseOpt := fs.String("release", "", "Upload the crawl archive and manifest to a release of this GitHub repository (owner/repo), the token is read from GITHUB_TOKEN")
+	releaseTagOpt := fs.String("release-tag", "latest", "Tag of the GitHub release to create or update")
+	pprofOpt := fs.String("pprof", "", "Serve pprof endpoints on this address (such as :6060)")
+	metricsAddrOpt := fs.String("metrics-addr", "", "Serve the phase timings of the crawls to Prometheus on this address (such as :9100), with -schedule")
+	uiAddrOpt := fs.String("ui-addr", "", "Serve a dashboard of the drops and the failures, from which they can be scraped again, on this address (such as :8080 for localhost only), with -schedule. Re-scrapes need the token read from UI_TOKEN, which is required on other interfaces")
+	cpuProfileOpt := fs.String("cpuprofile", "", "Write a CPU profile to this file")
+	memProfileOpt := fs.String("memprofile", "", "Write a heap profile to this file on exit")
+	traceOpt := fs.String("trace", "", "Write an execution trace to this file")
+	headersCacheOpt := fs.String("headers-cache", defaultHeadersCachePath(), "File where the Scryfall set headers are persisted between runs, empty to disable")
+	headersTTLOpt := fs.Duration("headers-ttl", defaultHeadersTTL, "How long the persisted Scryfall set headers are considered fresh")
+	filenamePolicyOpt := fs.String("filename-policy", "default", "How filenames are sanitized: default, windows (safe on any Windows filesystem) or posix")
+	filenameMaxBytesOpt := fs.Int("filename-max-bytes", defaultFilenameMaxBytes, "Longest filename allowed in bytes, without extension, 0 for no limit")
+	filenameASCIIOpt := fs.Bool("filename-ascii", false, "Transliterate filenames to ASCII")
+	replayOpt := fs.String("replay", "", "Answer every HTTP request with the responses recorded in this directory, failing the ones that weren't")
+	linksOpt := fs.String("links", "", "Scrape the product links of this file, a JSON array of {\"url\", \"note\"} objects or a browser bookmarks export, recording the notes in the exports")
+	fromHTMLOpt := fs.String("from-html", "", "Scrape the product page saved in this file, for the URL given as argument or else its canonical one")
+	recordOpt := fs.String("record", "", "Save every HTTP interaction of the run into this directory, to reproduce it later")
+	cookiesOpt := fs.String("cookies", "", "Preload storefront cookies from this file, in cookies.txt format or as name=value lines")
+	lockWaitOpt := fs.Duration("lock-wait", 0, "How long to wait for another instance writing to the same directory, instead of exiting right away")
+	auditOpt := fs.String("audit", "", "Append a JSON line to this file for every product fetched, Scryfall search, OCR attempt and file written")
+	stageRetriesOpt := fs.Int("stage-retries", defaultStageRetries, "How many times a failed stage of a product scrape (Scryfall search, image download and OCR) is retried before giving up on the product")
+	availabilityOpt := fs.String("availability", "", "Track in this file when each product appears in and disappears from the store, and add it to the drop metadata")
+	formatOpt := fs.String("format", "txt", "Format of the exported drops, txt or json")
+	lineFormatOpt := fs.String("line-format", "", "Go template for the card lines of txt files, such as '{{.Count}}x {{.Name}} ({{lower .Set}}) {{.Number}}', instead of the default format")
+	scalefastHostOpt := fs.String("scalefast-host", defaultScalefast.Host, "Host of the store search service")
+	scalefastUserOpt := fs.String("scalefast-user-id", defaultScalefast.UserID, "Store tenant in the store search service")
+	storeLocaleOpt := fs.String("store-locale", defaultScalefast.Locale, "Locale of the product titles and descriptions returned by the store search, such as ja_JP")
+	storeCurrencyOpt := fs.String("store-currency", defaultScalefast.Currency, "Currency requested from the store search, such as EUR")
+	evOpt := fs.Bool("ev", false, "Compute the value of the singles of every drop against its price, in the JSON output and the notifications")
+	reprintsOpt := fs.Bool("reprints", false, "Tell the cards new to Secret Lair apart from the reprints of earlier drops, in the log and the notifications")
+	bothFacesOpt := fs.Bool("both-faces", false, "Also list the back face of double-faced cards, numbered with a b suffix")
+	aggregateOpt := fs.Bool("aggregate", false, "Write identical printings on a single line with their total quantity, instead of one line for each copy")
+	skipComponentsOpt := fs.String("skip-components", "", "Comma-separated list of non-standard inclusions left out of the exports: oversized, display_commander, art_card")
+	acceptChangesOpt := fs.Bool("accept-changes", false, "Overwrite the exported drops whose cards change, instead of writing the new version next to them with a .new extension")
+	splitFinishesOpt := fs.Bool("split-finishes", false, "Write each finish of a drop to a separate file: the first of nonfoil, foil and etched keeps the name of the drop, the others go to \"<drop> Foil\" and \"<drop> Etched\"")
+	splitTokensOpt := fs.Bool("split-tokens", false, "Write the tokens of each drop to a separate \"<drop> Tokens\" file")
+	wikiCheckOpt := fs.Bool("wiki-check", false, "Compare the cards and numbers of every drop with its mtg.wiki page, and report any disagreement")
+	archiveOpt := fs.String("archive", "", "Also discover the products linked from this storefront listing page, such as the past drops, which may list products hidden from the store search")
+	priceCurrencyOpt := fs.String("price-currency", "", "Also convert the prices of the drops to this currency, such as USD, keeping the native ones")
+	priceRatesOpt := fs.String("price-rates", "ecb", "Exchange rates used by -price-currency: ecb for the daily rates of the European Central Bank, or a JSON file of rates against a common base")
+	sequenceOpt := fs.Bool("sequence", false, "Record the position of each drop in the Secret Lair series, among the drops exported so far")
+	needsAttentionOpt := fs.String("needs-attention", "", "Write the drops of the run with unmatched headers, missing numbers, count mismatches or backfilled numbers to this JSON report, such as needs-attention.json")
+	verifyImagesOpt := fs.String("verify-images", "", "OCR the gallery of the drops numbered from Scryfall too, writing the images that contradict their numbers to this JSON report")
+	sealedOpt := fs.String("sealed", "", "Write the sealed contents of the drops of the run to this file, in the MTGJSON format, as YAML with a .yaml extension and JSON otherwise")
+	combinedOpt := fs.String("combined", "", "Also append every drop of the run to this single file, one section per drop in order of release date")
+	filenameMapOpt := fs.String("filename-map", "", "Record the file name of every drop in this JSON file, by product link, including the ones suffixed to avoid a collision")
+	preordersOpt := fs.String("preorders", "", "Record the drops still in preorder in this file, scheduled crawls scrape them again once shipped")
+	newestOpt := fs.Bool("newest", false, "Crawl from the newest products, starting at page 0 unless -page is set, and stop at the first one already exported to the current directory or the database")
+	maxPagesOpt := fs.Int("max-pages", 0, "Stop the crawl after this many pages of the catalog, 0 for no limit")
+	onlyMissingOpt := fs.Bool("only-missing", false, "Go through the whole catalog, from the first page unless -page is set, and only scrape the products not exported yet to the current directory or the database")
+	includeBundlesOpt := fs.Bool("include-bundles", false, "Also scrape bundles, decks and other special products, recording their category")
+	aliasesOpt := fs.String("aliases", "", "File with additional store title => Scryfall header title aliases")
+	ocrFailuresOpt := fs.String("ocr-failures", "", "Remember in this file the gallery images whose OCR gave nothing usable, so that later runs skip them")
+	ocrForceOpt := fs.Bool("ocr-force", false, "Read again the images remembered by -ocr-failures")
+	ocrServerOpt := fs.String("ocr-server", "", "Send the images to this tesseract-server endpoint for OCR, such as http://localhost:8884/tesseract, instead of the local tesseract")
+	stageWorkersOpt := fs.String("stage-workers", "", "Workers of each crawl stage ("+strings.Join(pipelineStages, ", ")+"), such as 'fetch=2,ocr=4', one by default")
+	failuresOpt := fs.String("failures", "", "Record the products that failed to scrape in this file, to try them again with retry-failed")
+	pendingOpt := fs.String("pending", "", "Record the drops not yet known to Scryfall in this file, to try them again with retry-pending")
+	layoutOpt := fs.String("layout", layoutFlat, "Output layout: flat (one txt file per drop) or drop (one directory per drop with its cards, product page and images)")
+	noColorOpt := fs.Bool("no-color", false, "Disable colors in the console output")
+	jsonOpt := fs.Bool("json", false, "Also write each drop to a parallel JSON file, including the TCGplayer identifiers of the cards")
+	cardmarketOpt := fs.Bool("cardmarket", false, "Look up the Cardmarket product id of each card on Scryfall, implies -json")
+	mtgbanOpt := fs.Bool("mtgban", false, "Resolve the MTGBAN identifier of each card, implies -json")
+	tcgSealedOpt := fs.Bool("tcgplayer-sealed", false, "Resolve the TCGplayer product of each sealed drop from MTGJSON, implies -json")
+	annotateOpt := fs.Bool("annotate", false, "Tell how each number was found and flag the doubtful ones, as trailing comments of the txt files and fields of the JSON ones")
+	enrichOpt := fs.String("enrich", "", "Comma-separated list of external commands attaching more identifiers to each card, implies -json")
+	err := fs.Parse(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		return 2
+	}
+
+	stopProfiling, err := startProfiling(*pprofOpt, *cpuProfileOpt, *memProfileOpt, *traceOpt)
+	defer stopProfiling()
+	if err != nil {
+		log.Println("Unable to start profiling:", err)
+		return 1
+	}
+
+	if *recordOpt != "" && *replayOpt != "" {
+		log.Println("-record and -replay are mutually exclusive")
+		return 1
+	}
+	if *recordOpt != "" {
+		transport, err := newRecordingTransport(*recordOpt)
+		if err != nil {
+			log.Println("Unable to record:", err)
+			return 1
+		}
+		setHTTPTransport(transport)
+		defer setHTTPTransport(nil)
+	}
+	if *replayOpt != "" {
+		setHTTPTransport(&replayTransport{Dir: *replayOpt})
+		defer setHTTPTransport(nil)
+	}
+
+	// Created once the transport is set
+	scraper := newScraper(*headersCacheOpt, *headersTTLOpt)
+	scraper.OCR = *doOCROpt
+	scraper.SkipScryfall = *noScryfallOpt
+	scraper.VerifyImages = *verifyImagesOpt != ""
+	scraper.Retries = *stageRetriesOpt
+
+	var skippedComponents []string
+	for _, component := range strings.Split(*skipComponentsOpt, ",") {
+		component = strings.TrimSpace(component)
+		if component == "" {
+			continue
+		}
+		if !isComponent(component) {
+			log.Println("Unknown component", component)
+			return 1
+		}
+		skippedComponents = append(skippedComponents, component)
+	}
+
+	if *aliasesOpt != "" {
+		scraper.Aliases, err = loadAliases(*aliasesOpt)
+		if err != nil {
+			log.Println("Unable to load aliases:", err)
+			return 1
+		}
+	}
+
+	var audit *auditLog
+	if *auditOpt != "" {
+		audit, err = openAuditLog(*auditOpt)
+		if err != nil {
+			log.Println("Unable to open audit log:", err)
+			return 1
+		}
+		defer audit.Close()
+	}
+	scraper.audit = audit
+
+	if *cookiesOpt != "" {
+		err = loadCookies(scraper.storefront, *cookiesOpt)
+		if err != nil {
+			log.Println("Unable to load cookies:", err)
+			return 1
+		}
+	}
+
+	scraper.filenames = filenamePolicy{
+		Mode:     *filenamePolicyOpt,
+		MaxBytes: *filenameMaxBytesOpt,
+		ASCII:    *filenameASCIIOpt,
+	}
+	err = scraper.filenames.validate()
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	if *ocrServerOpt != "" {
+		scraper.ocr = remoteOCR{URL: *ocrServerOpt}
+	}
+	if *ocrFailuresOpt != "" {
+		scraper.ocrFailures, err = loadOCRFailureCache(*ocrFailuresOpt, *ocrForceOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+
+	stageWorkers, err := parseStageWorkers(*stageWorkersOpt)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	format, found := outputFormats[*formatOpt]
+	if !found {
+		log.Println("Unsupported format", *formatOpt)
+		return 1
+	}
+	if *lineFormatOpt != "" {
+		lineFormat, err := txtLineFormat(*lineFormatOpt)
+		if err != nil {
+			log.Println("Invalid line format:", err)
+			return 1
+		}
+		// Only the txt files have card lines
+		if format.Ext == lineFormat.Ext {
+			format = lineFormat
+		}
+	}
+
+	opts := crawlOptions{
+		Scraper:  scraper,
+		Format:   format,
+		Annotate: *annotateOpt,
+		Audit:    audit,
+		Feed:     *feedOpt,
+		JSON:     *jsonOpt,
+		Color:    useColor(*noColorOpt),
+
+		Layout:   *layoutOpt,
+		Pending:  *pendingOpt,
+		Failures: *failuresOpt,
+
+		StageWorkers: stageWorkers,
+		LockWait:     *lockWaitOpt,
+
+		Availability:   *availabilityOpt,
+		IncludeBundles: *includeBundlesOpt,
+		WikiCheck:      *wikiCheckOpt,
+		SplitTokens:    *splitTokensOpt,
+		SplitFinishes:  *splitFinishesOpt,
+		AcceptChanges:  *acceptChangesOpt,
+		SkipComponents: skippedComponents,
+		Aggregate:      *aggregateOpt,
+		BothFaces:      *bothFacesOpt,
+		OnlyMissing:    *onlyMissingOpt,
+		Newest:         *newestOpt,
+		MaxPages:       *maxPagesOpt,
+		Preorders:      *preordersOpt,
+		FilenameMap:    *filenameMapOpt,
+		Combined:       *combinedOpt,
+		Sealed:         *sealedOpt,
+		VerifyImages:   *verifyImagesOpt,
+		NeedsAttention: *needsAttentionOpt,
+		Sequence:       *sequenceOpt,
+		Reprints:       *reprintsOpt,
+		EV:             *evOpt,
+
+		Sources: []productSource{storeSearchSource{
+			Config: scalefastConfig{
+				Host:     *scalefastHostOpt,
+				UserID:   *scalefastUserOpt,
+				Locale:   *storeLocaleOpt,
+				Currency: *storeCurrencyOpt,
+			},
+			Audit: audit,
+		}},
+	}
+	if *archiveOpt != "" {
+		opts.Sources = append(opts.Sources, archiveSource{URL: *archiveOpt, Scraper: scraper})
+	}
+	if opts.Layout != layoutFlat && opts.Layout != layoutDrop {
+		log.Println("Unknown -layout", opts.Layout)
+		return 1
+	}
+	if *cardmarketOpt {
+		opts.Enrichers = append(opts.Enrichers, cardmarketEnricher{})
+	}
+	if *mtgbanOpt {
+		opts.Enrichers = append(opts.Enrichers, &mtgbanResolver{})
+	}
+	if *tcgSealedOpt {
+		opts.Enrichers = append(opts.Enrichers, &tcgplayerSealedResolver{})
+	}
+	if *priceCurrencyOpt != "" {
+		var source ratesSource = &ecbRates{}
+		if *priceRatesOpt != "ecb" {
+			source = fileRates{Path: *priceRatesOpt}
+		}
+		opts.Enrichers = append(opts.Enrichers, priceNormalizer{
+			Currency: strings.ToUpper(*priceCurrencyOpt),
+			Source:   source,
+		})
+	}
+	if *enrichOpt != "" {
+		for _, command := range strings.Split(*enrichOpt, ",") {
+			enricher, err := newExecEnricher(command)
+			if err != nil {
+				log.Println("Invalid -enrich:", err)
+				return 1
+			}
+			opts.Enrichers = append(opts.Enrichers, enricher)
+		}
+	}
+
+	if *smtpOpt != "" {
+		if *mailFromOpt == "" || *mailToOpt == "" {
+			log.Println("Missing -mail-from or -mail-to for email notifications")
+			return 1
+		}
+		opts.Notifiers = append(opts.Notifiers, &emailNotifier{
+			Addr:     *smtpOpt,
+			Username: *smtpUserOpt,
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     *mailFromOpt,
+			To:       strings.Split(*mailToOpt, ","),
+		})
+	}
+	if *ntfyOpt != "" {
+		filtered, err := newFilteredNotifier(&ntfyNotifier{
+			TopicURL: *ntfyOpt,
+			Token:    os.Getenv("NTFY_TOKEN"),
+		}, *pushEventsOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		opts.Notifiers = append(opts.Notifiers, filtered)
+	}
+	if *pushoverOpt != "" {
+		filtered, err := newFilteredNotifier(&pushoverNotifier{
+			Token: os.Getenv("PUSHOVER_TOKEN"),
+			User:  *pushoverOpt,
+		}, *pushEventsOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		opts.Notifiers = append(opts.Notifiers, filtered)
+	}
+	if *mqttOpt != "" {
+		mqttNotifier, err := newMQTTNotifier(*mqttOpt, *mqttTopicOpt, *mqttUserOpt, os.Getenv("MQTT_PASSWORD"))
+		if err != nil {
+			log.Println("Unable to connect to MQTT broker:", err)
+			return 1
+		}
+		defer mqttNotifier.Close()
+		opts.Notifiers = append(opts.Notifiers, mqttNotifier)
+	}
+	if *webhookOpt != "" {
+		hostname, _ := os.Hostname()
+		opts.Notifiers = append(opts.Notifiers, &webhookNotifier{
+			URL:    *webhookOpt,
+			Secret: os.Getenv("WEBHOOK_SECRET"),
+			Run: runMetadata{
+				StartedAt: time.Now().UTC(),
+				Hostname:  hostname,
+			},
+		})
+	}
+
+	if *dbOpt != "" {
+		store, err := openStorage(context.Background(), *dbOpt)
+		if err != nil {
+			log.Println("Unable to open storage:", err)
+			return 1
+		}
+		defer store.Close()
+		opts.Stores = append(opts.Stores, store)
+	}
+	if *sheetOpt != "" {
+		store, err := newSheetsStore(context.Background(), *googleCredsOpt, *sheetOpt, *sheetRangeOpt)
+		if err != nil {
+			log.Println("Unable to set up Google Sheets:", err)
+			return 1
+		}
+		opts.Stores = append(opts.Stores, store)
+	}
+	if *airtableOpt != "" {
+		fields, err := loadAirtableFields(*airtableFieldsOpt)
+		if err != nil {
+			log.Println("Unable to load Airtable fields:", err)
+			return 1
+		}
+		opts.Stores = append(opts.Stores, &airtableStore{
+			Token:      os.Getenv("AIRTABLE_TOKEN"),
+			BaseID:     *airtableOpt,
+			DropsTable: *airtableDropsOpt,
+			CardsTable: *airtableCardsOpt,
+			Fields:     fields,
+		})
+	}
+	if *signKeyOpt != "" {
+		if *manifestOpt == "" && *releaseOpt == "" {
+			log.Println("-sign-key requires -manifest or -release")
+			return 1
+		}
+		opts.Signer, err = loadManifestSigner(*signKeyOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+	opts.Manifest = *manifestOpt
+	if *releaseOpt != "" {
+		opts.Releaser = &githubReleaser{
+			Repo:   *releaseOpt,
+			Tag:    *releaseTagOpt,
+			Token:  os.Getenv("GITHUB_TOKEN"),
+			Ext:    format.Ext,
+			Signer: opts.Signer,
+		}
+	}
+
+	if *linksOpt != "" {
+		links, err := loadInputLinks(*linksOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		_, err = opts.Scraper.loadHeaders(opts.context())
+		if err != nil {
+			log.Println("Unable to query scryfall")
+			return 1
+		}
+		exported, err := scrapeLinks(links, opts)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		log.Println("Exported", exported, "of", len(links), "drops")
+		if exported < len(links) {
+			return 1
+		}
+		return 0
+	}
+
+	args = fs.Args()
+	if *fromHTMLOpt != "" {
+		page, err := os.ReadFile(*fromHTMLOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		link := savedPageLink(page)
+		if len(args) > 0 {
+			link = args[0]
+		}
+		if link == "" {
+			log.Println("No URL found in", *fromHTMLOpt, "pass it as argument")
+			return 1
+		}
+		scraper.savedPages[link] = *fromHTMLOpt
+		args = []string{link}
+	}
+
+	for i, arg := range args {
+		_, err := opts.Scraper.loadHeaders(opts.context())
+		if err != nil {
+			log.Println("Unable to query scryfall")
+			return 1
+		}
+
+		result, err := opts.Scraper.scrapeProduct(arg, "")
+		if err != nil {
+			log.Println("page", i, "-", err)
+			opts.notifyFailure(arg, err)
+			return 1
+		}
+		cardSet := result.CardSet
+		if len(result.Warnings) > 0 {
+			log.Println("Warnings:", summarizeWarnings([]*ScrapeResult{result}))
+		}
+		if opts.VerifyImages != "" {
+			err = writeMismatchReport(opts.VerifyImages, []*ScrapeResult{result})
+			if err != nil {
+				log.Println(err)
+			}
+		}
+		if opts.NeedsAttention != "" {
+			err = writeAttentionReport(opts.NeedsAttention, []*ScrapeResult{result})
+			if err != nil {
+				log.Println(err)
+			}
+		}
+		printCardSet(os.Stderr, cardSet, opts.Color)
+		opts.checkRegistry(cardSet)
+		if opts.Reprints {
+			cardSet.NewCards, cardSet.Reprints = classifyPrintings(opts.context(), cardSet)
+			reportPrintings([]*CardSet{cardSet})
+		}
+		if opts.EV {
+			valueDrop(opts.context(), cardSet)
+		}
+
+		start := time.Now()
+		written, err := exportCardSet(cardSet, "", opts)
+		cardSet.timings.observe("export", start)
+		log.Println("Timings:", cardSet.timings)
+		if err != nil {
+			log.Println(err)
+			opts.notifyFailure(arg, err)
+			return 1
+		}
+		if written == fileHeld {
+			log.Println(errHeld)
+			return 1
+		}
+		opts.notifyDrop(cardSet)
+
+		if opts.Feed != "" {
+			err = updateFeed(opts.Feed, []*CardSet{cardSet})
+			if err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+		if opts.Combined != "" {
+			err = appendCombined(opts.Combined, []*CardSet{cardSet})
+			if err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+		if opts.Sealed != "" {
+			err = writeSealedContents(opts.Sealed, []*CardSet{cardSet})
+			if err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+		return 0
+	}
+
+	if *pageOpt == 0 && *onlyMissingOpt {
+		*pageOpt = 1
+	}
+	if *pageOpt == 0 && !*newestOpt {
+		log.Println("Missing starting -page argument")
+		return 1
+	}
+
+	if *scheduleOpt != "" {
+		if *metricsAddrOpt != "" {
+			serveMetrics(*metricsAddrOpt)
+		}
+		if *uiAddrOpt != "" {
+			err := serveWebUI(*uiAddrOpt, os.Getenv("UI_TOKEN"), opts)
+			if err != nil {
+				log.Println(err)
+				return 1
+			}
+		}
+		err := runScheduled(*scheduleOpt, *jitterOpt, *pageOpt, opts)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		return 0
+	}
+
+	next, err := crawl(*pageOpt, opts)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	fmt.Fprintln(os.Stdout, "In the future you can start from page", next)
+
+	return 0
+}
@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"github.com/mtgban/sldownloader"
+)
+
+func main() {
+	os.Exit(sldownloader.Run(os.Args[1:]))
+}
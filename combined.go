@@ -0,0 +1,49 @@
+package sldownloader
+
+import (
+	"bufio"
+	"cmp"
+	"fmt"
+	"os"
+	"slices"
+)
+
+// Append the drops of a run to a single file, each in its own section, in
+// order of release date with the undated ones last
+func appendCombined(path string, cardSets []*CardSet) error {
+	if len(cardSets) == 0 {
+		return nil
+	}
+
+	sorted := slices.Clone(cardSets)
+	slices.SortStableFunc(sorted, func(a, b *CardSet) int {
+		if (a.ReleaseDate == "") != (b.ReleaseDate == "") {
+			if a.ReleaseDate == "" {
+				return 1
+			}
+			return -1
+		}
+		return cmp.Compare(a.ReleaseDate, b.ReleaseDate)
+	})
+
+	file, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	w := bufio.NewWriter(file)
+	for _, cardSet := range sorted {
+		fmt.Fprintf(w, "// ===== %s =====\n", cardSet.Title)
+		err = writeTxt(w, cardSet)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	err = w.Flush()
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
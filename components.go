@@ -0,0 +1,70 @@
+package sldownloader
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// Kinds of non-standard inclusions of a drop, which are not playable cards
+const (
+	componentOversized        = "oversized"
+	componentDisplayCommander = "display_commander"
+	componentArtCard          = "art_card"
+)
+
+type componentMarker struct {
+	Component string
+	Markers   []string
+}
+
+// Words of the product text announcing each component, lowercase and with the
+// most specific first since display commanders may also be oversized
+var componentMarkers = []componentMarker{
+	{componentDisplayCommander, []string{"display commander"}},
+	{componentOversized, []string{"oversized"}},
+	{componentArtCard, []string{"art card"}},
+}
+
+func isComponent(tag string) bool {
+	return slices.ContainsFunc(componentMarkers, func(marker componentMarker) bool {
+		return marker.Component == tag
+	})
+}
+
+// Component announced by a line of the product text, empty for regular cards
+func lineComponent(line string) string {
+	line = strings.ToLower(line)
+	for _, marker := range componentMarkers {
+		for _, text := range marker.Markers {
+			if strings.Contains(line, text) {
+				return marker.Component
+			}
+		}
+	}
+	return ""
+}
+
+// Component of a Scryfall printing, empty for regular cards
+func scryfallComponent(card scryfall.Card) string {
+	switch {
+	case slices.Contains(card.PromoTypes, "thick"):
+		return componentDisplayCommander
+	case card.Oversized:
+		return componentOversized
+	case card.Layout == "art_series":
+		return componentArtCard
+	}
+	return ""
+}
+
+// Drop the cards of the given components
+func skipComponents(cards []CardData, components []string) []CardData {
+	if len(components) == 0 {
+		return cards
+	}
+	return slices.DeleteFunc(cards, func(card CardData) bool {
+		return card.Component != "" && slices.Contains(components, card.Component)
+	})
+}
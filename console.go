@@ -0,0 +1,100 @@
+package sldownloader
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+)
+
+// Where the collector number of a card comes from
+type numberSource int
+
+const (
+	sourceNone numberSource = iota
+	sourceScryfall
+	sourceOCR
+	sourceBackfill
+	sourceCaption
+)
+
+func (source numberSource) String() string {
+	switch source {
+	case sourceScryfall:
+		return "scryfall"
+	case sourceOCR:
+		return "ocr"
+	case sourceBackfill:
+		return "backfill"
+	case sourceCaption:
+		return "caption"
+	}
+	return "missing"
+}
+
+const (
+	colorReset  = "\033[0m"
+	colorRed    = "\033[31m"
+	colorGreen  = "\033[32m"
+	colorYellow = "\033[33m"
+)
+
+func (source numberSource) color() string {
+	switch source {
+	case sourceScryfall, sourceOCR, sourceCaption:
+		return colorGreen
+	case sourceBackfill:
+		return colorYellow
+	}
+	return colorRed
+}
+
+// Only use colors on terminals, unless disabled by NO_COLOR
+func useColor(noColor bool) bool {
+	if noColor || os.Getenv("NO_COLOR") != "" {
+		return false
+	}
+	info, err := os.Stderr.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+func pad(s string, width int) string {
+	return s + strings.Repeat(" ", max(0, width-utf8.RuneCountInString(s)))
+}
+
+// Print the cards of a drop in columns, highlighting how each number was found
+func printCardSet(w io.Writer, cardSet *CardSet, color bool) {
+	numberWidth := len("----")
+	nameWidth := 0
+	for _, card := range cardSet.Cards {
+		numberWidth = max(numberWidth, len(card.Number))
+		nameWidth = max(nameWidth, utf8.RuneCountInString(card.Name))
+	}
+
+	fmt.Fprintln(w, cardSet.Title)
+	for _, card := range cardSet.Cards {
+		number := card.Number
+		if number == "" {
+			number = "----"
+		}
+		number = pad(number, numberWidth)
+		if color {
+			number = card.source.color() + number + colorReset
+		}
+
+		var tags []string
+		if card.Foil {
+			tags = append(tags, "foil")
+		}
+		if card.Etched {
+			tags = append(tags, "etched")
+		}
+		if card.Token {
+			tags = append(tags, "token")
+		}
+
+		fmt.Fprintf(w, "  %3dx  %s  %s  %s  %s\n", card.Count, number,
+			pad(card.Name, nameWidth), pad(strings.Join(tags, ","), len("foil,etched,token")), card.source)
+	}
+}
@@ -0,0 +1,114 @@
+package sldownloader
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Parse previously generated files and write them again in another format
+func runConvert(args []string) int {
+	var names []string
+	for name := range outputFormats {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+
+	fs := flag.NewFlagSet("convert", flag.ExitOnError)
+	fromOpt := fs.String("from", "txt", "Format of the input files ("+strings.Join(names, ", ")+")")
+	toOpt := fs.String("to", "json", "Format of the output files ("+strings.Join(names, ", ")+")")
+	outOpt := fs.String("out", ".", "Directory where converted files are written")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader convert -from txt -to json [-out dir] file...")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	from, found := outputFormats[*fromOpt]
+	if !found {
+		log.Println("Unsupported input format", *fromOpt)
+		return 1
+	}
+	to, found := outputFormats[*toOpt]
+	if !found {
+		log.Println("Unsupported output format", *toOpt)
+		return 1
+	}
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		info, err := os.Stat(arg)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		if !info.IsDir() {
+			files = append(files, arg)
+			continue
+		}
+		matches, err := filepath.Glob(filepath.Join(arg, "*"+from.Ext))
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		files = append(files, matches...)
+	}
+
+	failed := false
+	for _, path := range files {
+		err := convertFile(path, *outOpt, from, to)
+		if err != nil {
+			log.Println(path, "-", err)
+			failed = true
+		}
+	}
+
+	if failed {
+		return 1
+	}
+	return 0
+}
+
+func convertFile(path, outDir string, from, to outputFormat) error {
+	in, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	cardSet, err := from.Read(in)
+	if err != nil {
+		return err
+	}
+	if cardSet.Filename == "" {
+		cardSet.Filename = strings.TrimSuffix(filepath.Base(path), from.Ext)
+	}
+
+	// Filenames of the drop layout include their directory
+	outPath := filepath.Join(outDir, cardSet.Filename+to.Ext)
+	err = os.MkdirAll(filepath.Dir(outPath), 0755)
+	if err != nil {
+		return err
+	}
+	out, err := os.Create(outPath)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	err = to.Write(out, cardSet)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("Converted '%s' to '%s'", path, outPath)
+	return out.Close()
+}
@@ -0,0 +1,74 @@
+package sldownloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// Split the cards of the drop between the ones appearing in Secret Lair for
+// the first time and the reprints of earlier drops, by oracle identity
+func classifyPrintings(ctx context.Context, cardSet *CardSet) (newCards, reprints []string) {
+	numbers := map[string]bool{}
+	for _, card := range cardSet.Cards {
+		numbers[baseNumber(card.Number)] = true
+	}
+
+	for _, card := range cardSet.Cards {
+		if card.Token || card.Number == "" ||
+			slices.Contains(newCards, card.Name) || slices.Contains(reprints, card.Name) {
+			continue
+		}
+
+		// Reversible cards have no oracle identity of their own
+		query := fmt.Sprintf("e:sld !\"%s\"", card.Name)
+		if card.oracleID != "" {
+			query = fmt.Sprintf("e:sld oracleid:%s", card.oracleID)
+		}
+		printings, err := searchCards(ctx, query)
+		if err != nil {
+			log.Println("Unable to look up the printings of", card.Name+":", err)
+			continue
+		}
+
+		released := time.Now()
+		for _, printing := range printings {
+			if printing.CollectorNumber == baseNumber(card.Number) {
+				released = printing.ReleasedAt.Time
+				break
+			}
+		}
+
+		reprint := slices.ContainsFunc(printings, func(printing scryfall.Card) bool {
+			return !numbers[printing.CollectorNumber] && printing.ReleasedAt.Before(released)
+		})
+		if reprint {
+			reprints = append(reprints, card.Name)
+		} else {
+			newCards = append(newCards, card.Name)
+		}
+	}
+
+	return newCards, reprints
+}
+
+// Log which cards of the crawled drops are new to Secret Lair
+func reportPrintings(cardSets []*CardSet) {
+	for _, cardSet := range cardSets {
+		if len(cardSet.NewCards) == 0 && len(cardSet.Reprints) == 0 {
+			continue
+		}
+		log.Printf("%s: %d new to Secret Lair, %d reprints", cardSet.Title, len(cardSet.NewCards), len(cardSet.Reprints))
+		if len(cardSet.NewCards) > 0 {
+			log.Println("  New:", strings.Join(cardSet.NewCards, ", "))
+		}
+		if len(cardSet.Reprints) > 0 {
+			log.Println("  Reprints:", strings.Join(cardSet.Reprints, ", "))
+		}
+	}
+}
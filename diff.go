@@ -0,0 +1,205 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+)
+
+// Differences between two versions of the same drop
+type dropDiff struct {
+	Filename   string       `json:"filename"`
+	Status     string       `json:"status"`
+	Added      []CardData   `json:"added,omitempty"`
+	Removed    []CardData   `json:"removed,omitempty"`
+	Renumbered []renumbered `json:"renumbered,omitempty"`
+}
+
+type renumbered struct {
+	Card      CardData `json:"card"`
+	OldNumber string   `json:"old_number"`
+}
+
+func (d dropDiff) empty() bool {
+	return d.Status == "changed" && len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Renumbered) == 0
+}
+
+// Identify the same card across versions, regardless of its number
+func cardKey(card CardData) string {
+	return fmt.Sprintf("%s|%t|%t|%t|%s", card.Name, card.Foil, card.Etched, card.Token, card.Component)
+}
+
+func diffCardSets(oldSet, newSet *CardSet) dropDiff {
+	diff := dropDiff{
+		Filename: newSet.Filename,
+		Status:   "changed",
+	}
+
+	oldCards := map[string][]CardData{}
+	var keys []string
+	for _, card := range oldSet.Cards {
+		key := cardKey(card)
+		if _, found := oldCards[key]; !found {
+			keys = append(keys, key)
+		}
+		oldCards[key] = append(oldCards[key], card)
+	}
+	newCards := map[string][]CardData{}
+	for _, card := range newSet.Cards {
+		key := cardKey(card)
+		if _, found := oldCards[key]; !found {
+			if _, found := newCards[key]; !found {
+				keys = append(keys, key)
+			}
+		}
+		newCards[key] = append(newCards[key], card)
+	}
+
+	for _, key := range keys {
+		olds := slices.Clone(oldCards[key])
+		news := slices.Clone(newCards[key])
+
+		// Drop the cards present with the same number on both sides
+		news = slices.DeleteFunc(news, func(card CardData) bool {
+			idx := slices.IndexFunc(olds, func(old CardData) bool {
+				return old.Number == card.Number
+			})
+			if idx < 0 {
+				return false
+			}
+			olds = slices.Delete(olds, idx, idx+1)
+			return true
+		})
+
+		// Whatever is left on both sides changed number
+		n := min(len(olds), len(news))
+		for i := 0; i < n; i++ {
+			diff.Renumbered = append(diff.Renumbered, renumbered{
+				Card:      news[i],
+				OldNumber: olds[i].Number,
+			})
+		}
+		diff.Removed = append(diff.Removed, olds[n:]...)
+		diff.Added = append(diff.Added, news[n:]...)
+	}
+
+	return diff
+}
+
+// Pair the files of two directories by name, or the two input files directly
+func diffPaths(oldPath, newPath string) ([]dropDiff, error) {
+	info, err := os.Stat(oldPath)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		oldSet, err := loadCardSet(oldPath)
+		if err != nil {
+			return nil, err
+		}
+		newSet, err := loadCardSet(newPath)
+		if err != nil {
+			return nil, err
+		}
+		return []dropDiff{diffCardSets(oldSet, newSet)}, nil
+	}
+
+	oldFiles, err := listOutputFiles([]string{oldPath}, outputFormats["txt"])
+	if err != nil {
+		return nil, err
+	}
+	newFiles, err := listOutputFiles([]string{newPath}, outputFormats["txt"])
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range append(oldFiles, newFiles...) {
+		name := filepath.Base(file)
+		if !slices.Contains(names, name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+
+	var diffs []dropDiff
+	for _, name := range names {
+		oldSet, oldErr := loadCardSet(filepath.Join(oldPath, name))
+		newSet, newErr := loadCardSet(filepath.Join(newPath, name))
+		switch {
+		case oldErr != nil && newErr != nil:
+			return nil, newErr
+		case oldErr != nil:
+			diffs = append(diffs, dropDiff{
+				Filename: newSet.Filename,
+				Status:   "added",
+				Added:    newSet.Cards,
+			})
+		case newErr != nil:
+			diffs = append(diffs, dropDiff{
+				Filename: oldSet.Filename,
+				Status:   "removed",
+				Removed:  oldSet.Cards,
+			})
+		default:
+			diffs = append(diffs, diffCardSets(oldSet, newSet))
+		}
+	}
+
+	return diffs, nil
+}
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ExitOnError)
+	jsonOpt := fs.Bool("json", false, "Output the report as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader diff [-json] old.txt new.txt")
+		fmt.Fprintln(fs.Output(), "       sldownloader diff [-json] old-dir/ new-dir/")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		return 1
+	}
+
+	diffs, err := diffPaths(fs.Arg(0), fs.Arg(1))
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	diffs = slices.DeleteFunc(diffs, dropDiff.empty)
+
+	if *jsonOpt {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(diffs)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+	} else {
+		for _, diff := range diffs {
+			fmt.Printf("%s (%s)\n", diff.Filename, diff.Status)
+			for _, card := range diff.Removed {
+				fmt.Println("-", formatCard(card))
+			}
+			for _, card := range diff.Added {
+				fmt.Println("+", formatCard(card))
+			}
+			for _, change := range diff.Renumbered {
+				fmt.Printf("~ %s (was %s)\n", formatCard(change.Card), change.OldNumber)
+			}
+		}
+	}
+
+	if len(diffs) > 0 {
+		return 1
+	}
+	return 0
+}
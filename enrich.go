@@ -0,0 +1,83 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// An enricher attaches identifiers to the cards of a drop before it is
+// exported, such as the ids used by a vendor or a price aggregator
+type enricher interface {
+	Enrich(ctx context.Context, cardSet *CardSet) error
+}
+
+type cardmarketEnricher struct{}
+
+func (cardmarketEnricher) Enrich(ctx context.Context, cardSet *CardSet) error {
+	return resolveCardmarketIDs(ctx, cardSet)
+}
+
+// Run an external program to enrich the cards, so that vendor mappings can be
+// maintained outside of this repository.
+//
+// The program receives the card set as JSON on stdin, and must print a JSON
+// array with one object per card, in the same order, mapping the name of
+// each identifier to its value, for example
+//
+//	[{"cardkingdom": "12345"}, {}, {"scg": "SLD-0042"}]
+//
+// Any error is reported by exiting with a non-zero status.
+type execEnricher struct {
+	Command []string
+}
+
+func newExecEnricher(command string) (*execEnricher, error) {
+	fields := strings.Fields(command)
+	if len(fields) == 0 {
+		return nil, errors.New("empty enricher command")
+	}
+	return &execEnricher{Command: fields}, nil
+}
+
+func (e *execEnricher) Enrich(ctx context.Context, cardSet *CardSet) error {
+	input, err := json.Marshal(cardSet)
+	if err != nil {
+		return err
+	}
+
+	var output bytes.Buffer
+	cmd := exec.CommandContext(ctx, e.Command[0], e.Command[1:]...)
+	cmd.Stdin = bytes.NewReader(input)
+	cmd.Stdout = &output
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if err != nil {
+		return err
+	}
+
+	var identifiers []map[string]string
+	err = json.Unmarshal(output.Bytes(), &identifiers)
+	if err != nil {
+		return err
+	}
+	if len(identifiers) != len(cardSet.Cards) {
+		return fmt.Errorf("expected %d entries, got %d", len(cardSet.Cards), len(identifiers))
+	}
+
+	for i := range cardSet.Cards {
+		for key, value := range identifiers[i] {
+			if cardSet.Cards[i].Identifiers == nil {
+				cardSet.Cards[i].Identifiers = map[string]string{}
+			}
+			cardSet.Cards[i].Identifiers[key] = value
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,290 @@
+package sldownloader
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"math"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// Scryfall prices of a card in US dollars, empty when unknown
+type usdPrices struct {
+	Nonfoil string
+	Foil    string
+	Etched  string
+}
+
+func scryfallPrices(card scryfall.Card) usdPrices {
+	return usdPrices{
+		Nonfoil: card.Prices.USD,
+		Foil:    card.Prices.USDFoil,
+		Etched:  card.Prices.USDEtched,
+	}
+}
+
+// Price of a single copy of the card in its finish
+func cardPrice(card CardData) (float64, bool) {
+	price := card.prices.Nonfoil
+	switch cardFinish(card) {
+	case "etched":
+		price = card.prices.Etched
+	case "foil":
+		price = card.prices.Foil
+	}
+	value, err := strconv.ParseFloat(price, 64)
+	return value, err == nil
+}
+
+// Value of the cards of a drop in one finish, compared to the price of the
+// offer for that finish when the store sells the finishes separately
+type finishValue struct {
+	Finish string  `json:"finish"`
+	Cards  int     `json:"cards"`
+	Value  float64 `json:"value"`
+	Price  float64 `json:"price,omitempty"`
+	// Cards whose price is unknown, which the value leaves out
+	Unpriced int `json:"unpriced,omitempty"`
+}
+
+// Expected value of a drop, as the sum of the prices of its singles, in US
+// dollars
+type dropValue struct {
+	Value float64 `json:"value"`
+	// Price of the drop when sold as a single offer
+	Price    float64       `json:"price,omitempty"`
+	Finishes []finishValue `json:"finishes"`
+}
+
+// Ratio of the value to the price, zero when the price is unknown
+func ratio(value, price float64) float64 {
+	if price == 0 {
+		return 0
+	}
+	return value / price
+}
+
+func (value dropValue) String() string {
+	out := fmt.Sprintf("%.2f USD", value.Value)
+	if value.Price > 0 {
+		out += fmt.Sprintf(" for %.2f USD (%.2fx)", value.Price, ratio(value.Value, value.Price))
+	}
+	var finishes []string
+	for _, finish := range value.Finishes {
+		part := fmt.Sprintf("%s %.2f", finish.Finish, finish.Value)
+		if finish.Price > 0 {
+			part += fmt.Sprintf(" for %.2f (%.2fx)", finish.Price, ratio(finish.Value, finish.Price))
+		}
+		if finish.Unpriced > 0 {
+			part += fmt.Sprintf(", %d unpriced", finish.Unpriced)
+		}
+		finishes = append(finishes, part)
+	}
+	if len(finishes) > 1 || (len(finishes) == 1 && value.Finishes[0].Unpriced > 0) {
+		out += " - " + strings.Join(finishes, "; ")
+	}
+	return out
+}
+
+// Price of an offer in US dollars, either native or converted
+func usdAmount(price dropPrice) (float64, bool) {
+	if price.Currency == "USD" {
+		return price.Amount, true
+	}
+	if price.Reference != nil && price.Reference.Currency == "USD" {
+		return price.Reference.Amount, true
+	}
+	return 0, false
+}
+
+// Finish an offer is for, from its label, empty when it can't be told
+func offerFinish(label string) string {
+	label = strings.ToLower(label)
+	switch {
+	case strings.Contains(label, "etched"):
+		return "etched"
+	case strings.Contains(label, "non-foil"), strings.Contains(label, "nonfoil"),
+		strings.Contains(label, "non foil"), strings.Contains(label, "regular"):
+		return "nonfoil"
+	case strings.Contains(label, "foil"):
+		return "foil"
+	}
+	return ""
+}
+
+// Compute the value of the singles of the drop, by finish, against the
+// prices of its offers
+func computeDropValue(cardSet *CardSet) dropValue {
+	var value dropValue
+	for _, card := range cardSet.Cards {
+		finish := cardFinish(card)
+		i := slices.IndexFunc(value.Finishes, func(fv finishValue) bool {
+			return fv.Finish == finish
+		})
+		if i < 0 {
+			value.Finishes = append(value.Finishes, finishValue{Finish: finish})
+			i = len(value.Finishes) - 1
+		}
+
+		count := max(card.Count, 1)
+		value.Finishes[i].Cards += count
+		price, found := cardPrice(card)
+		if !found {
+			value.Finishes[i].Unpriced += count
+			continue
+		}
+		value.Finishes[i].Value += price * float64(count)
+	}
+	slices.SortFunc(value.Finishes, func(a, b finishValue) int {
+		return cmp.Compare(a.Finish, b.Finish)
+	})
+
+	for i := range value.Finishes {
+		value.Finishes[i].Value = math.Round(value.Finishes[i].Value*100) / 100
+		value.Value += value.Finishes[i].Value
+	}
+	value.Value = math.Round(value.Value*100) / 100
+
+	for _, price := range cardSet.Prices {
+		amount, found := usdAmount(price)
+		if !found {
+			continue
+		}
+		finish := offerFinish(price.Label)
+		if finish == "" {
+			// The cheapest offer when the finish isn't told apart
+			if value.Price == 0 || amount < value.Price {
+				value.Price = amount
+			}
+			continue
+		}
+		for i := range value.Finishes {
+			if value.Finishes[i].Finish == finish {
+				value.Finishes[i].Price = amount
+			}
+		}
+	}
+	return value
+}
+
+// Look up on Scryfall the prices of the numbered cards lacking them, such as
+// the ones loaded from the exported files or not matched by a search
+func fillPrices(ctx context.Context, cardSet *CardSet) error {
+	numbers := map[string][]string{}
+	for _, card := range cardSet.Cards {
+		if card.Number == "" || card.prices != (usdPrices{}) {
+			continue
+		}
+		set := scryfallSetCode(card)
+		number := baseNumber(card.Number)
+		if !slices.Contains(numbers[set], number) {
+			numbers[set] = append(numbers[set], number)
+		}
+	}
+
+	for _, set := range slices.Sorted(maps.Keys(numbers)) {
+		var terms []string
+		for _, number := range numbers[set] {
+			terms = append(terms, "cn:"+strconv.Quote(number))
+		}
+		query := fmt.Sprintf("e:%s (%s)", set, strings.Join(terms, " or "))
+		printings, err := searchCards(ctx, query)
+		if err != nil {
+			return err
+		}
+
+		for i, card := range cardSet.Cards {
+			if card.Number == "" || scryfallSetCode(card) != set {
+				continue
+			}
+			for _, printing := range printings {
+				if printing.CollectorNumber == baseNumber(card.Number) {
+					cardSet.Cards[i].prices = scryfallPrices(printing)
+					break
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Price the cards of the drop and record its value
+func valueDrop(ctx context.Context, cardSet *CardSet) {
+	err := fillPrices(ctx, cardSet)
+	if err != nil {
+		log.Println("Unable to look up the prices of", cardSet.Title+":", err)
+	}
+	value := computeDropValue(cardSet)
+	cardSet.Value = &value
+}
+
+func runEV(args []string) int {
+	fs := flag.NewFlagSet("ev", flag.ExitOnError)
+	jsonOpt := fs.Bool("json", false, "Output the values as JSON")
+	priceOpt := fs.Float64("price", 0, "Price of the drops in US dollars, when the files don't record it")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader ev [-json] [-price 29.99] dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	type drop struct {
+		File  string `json:"file"`
+		Title string `json:"title"`
+		dropValue
+	}
+	var drops []drop
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		err = fillPrices(context.Background(), cardSet)
+		if err != nil {
+			log.Println(path, "-", err)
+			continue
+		}
+		value := computeDropValue(cardSet)
+		if *priceOpt > 0 {
+			value.Price = *priceOpt
+		}
+		drops = append(drops, drop{File: path, Title: cardSet.Title, dropValue: value})
+	}
+
+	if *jsonOpt {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(drops)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		return 0
+	}
+
+	for _, drop := range drops {
+		fmt.Printf("%s: %s\n", drop.Title, drop.dropValue)
+	}
+	return 0
+}
@@ -0,0 +1,219 @@
+package sldownloader
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A consolidated set file in the style of MTGJSON, combining every drop
+type setFile struct {
+	Meta setFileMeta `json:"meta"`
+	Data setFileData `json:"data"`
+}
+
+type setFileMeta struct {
+	Date    string `json:"date"`
+	Version string `json:"version"`
+}
+
+type setFileData struct {
+	Code         string        `json:"code"`
+	Name         string        `json:"name"`
+	TotalSetSize int           `json:"totalSetSize"`
+	Cards        []setFileCard `json:"cards"`
+	Tokens       []setFileCard `json:"tokens"`
+}
+
+type setFileCard struct {
+	Name        string            `json:"name"`
+	Number      string            `json:"number"`
+	SetCode     string            `json:"setCode"`
+	Finishes    []string          `json:"finishes"`
+	Language    string            `json:"language,omitempty"`
+	Identifiers map[string]string `json:"identifiers,omitempty"`
+	Drops       []setFileDrop     `json:"drops"`
+}
+
+// Provenance of a card in the set file
+type setFileDrop struct {
+	Title       string `json:"title"`
+	Link        string `json:"link,omitempty"`
+	ReleaseDate string `json:"releaseDate,omitempty"`
+	// Release date shared with other drops, which identifies the superdrop
+	Superdrop string `json:"superdrop,omitempty"`
+	Count     int    `json:"count"`
+}
+
+// Sort collector numbers numerically, then by their suffix
+func compareNumbers(a, b string) int {
+	na, _ := strconv.Atoi(strings.TrimRightFunc(a, func(r rune) bool { return r < '0' || r > '9' }))
+	nb, _ := strconv.Atoi(strings.TrimRightFunc(b, func(r rune) bool { return r < '0' || r > '9' }))
+	if na != nb {
+		return na - nb
+	}
+	return strings.Compare(a, b)
+}
+
+func buildSetFile(cardSets []*CardSet) setFile {
+	dropsPerDate := map[string]int{}
+	for _, cardSet := range cardSets {
+		if cardSet.ReleaseDate != "" {
+			dropsPerDate[cardSet.ReleaseDate]++
+		}
+	}
+
+	// Printings are identified by their number, or their name when unnumbered
+	var keys []string
+	printings := map[string]*setFileCard{}
+	tokens := map[string]bool{}
+	for _, cardSet := range cardSets {
+		drop := setFileDrop{
+			Title:       cardSet.Title,
+			Link:        cardSet.Link,
+			ReleaseDate: cardSet.ReleaseDate,
+		}
+		if dropsPerDate[cardSet.ReleaseDate] > 1 {
+			drop.Superdrop = cardSet.ReleaseDate
+		}
+
+		for _, card := range cardSet.Cards {
+			setCode := "SLD"
+			if card.Set != "" {
+				setCode = card.Set
+			}
+			key := setCode + ":" + card.Number
+			if card.Number == "" {
+				key = setCode + ":" + card.Name
+			}
+
+			printing, found := printings[key]
+			if !found {
+				printing = &setFileCard{
+					Name:        card.Name,
+					Number:      card.Number,
+					SetCode:     setCode,
+					Language:    card.Language,
+					Identifiers: map[string]string{},
+				}
+				printings[key] = printing
+				tokens[key] = card.Token
+				keys = append(keys, key)
+			}
+
+			finish := cardFinish(card)
+			if !slices.Contains(printing.Finishes, finish) {
+				printing.Finishes = append(printing.Finishes, finish)
+			}
+			if card.MTGBANID != "" {
+				printing.Identifiers["mtgbanId"] = card.MTGBANID
+			}
+			if card.TCGplayerID != 0 {
+				printing.Identifiers["tcgplayerProductId"] = strconv.Itoa(card.TCGplayerID)
+			}
+			if card.CardmarketID != 0 {
+				printing.Identifiers["mcmId"] = strconv.Itoa(card.CardmarketID)
+			}
+			for name, id := range card.Identifiers {
+				printing.Identifiers[name] = id
+			}
+
+			drop.Count = card.Count
+			printing.Drops = append(printing.Drops, drop)
+		}
+	}
+
+	out := setFile{
+		Meta: setFileMeta{
+			Date:    time.Now().UTC().Format(time.DateOnly),
+			Version: "sldownloader " + version,
+		},
+		Data: setFileData{
+			Code: "SLD",
+			Name: "Secret Lair Drop",
+		},
+	}
+	for _, key := range keys {
+		printing := printings[key]
+		slices.Sort(printing.Finishes)
+		if len(printing.Identifiers) == 0 {
+			printing.Identifiers = nil
+		}
+		if tokens[key] {
+			out.Data.Tokens = append(out.Data.Tokens, *printing)
+		} else {
+			out.Data.Cards = append(out.Data.Cards, *printing)
+		}
+	}
+
+	sortPrintings := func(a, b setFileCard) int {
+		return cmp.Or(compareNumbers(a.Number, b.Number), strings.Compare(a.Name, b.Name))
+	}
+	slices.SortStableFunc(out.Data.Cards, sortPrintings)
+	slices.SortStableFunc(out.Data.Tokens, sortPrintings)
+	out.Data.TotalSetSize = len(out.Data.Cards)
+
+	return out
+}
+
+func runExportSet(args []string) int {
+	fs := flag.NewFlagSet("export-set", flag.ExitOnError)
+	outOpt := fs.String("out", "", "File where the set is written, stdout if empty")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader export-set [-out SLD.json] dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	var cardSets []*CardSet
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		cardSets = append(cardSets, cardSet)
+	}
+
+	var w io.Writer = os.Stdout
+	if *outOpt != "" {
+		file, err := os.Create(*outOpt)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		defer file.Close()
+		w = file
+	}
+
+	set := buildSetFile(cardSets)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(set)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Printf("Exported %d printings and %d tokens from %d drops", len(set.Data.Cards), len(set.Data.Tokens), len(cardSets))
+	return 0
+}
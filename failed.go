@@ -0,0 +1,214 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// Delay before retrying a product after its first failure, doubled after each
+// further failure up to maxFailureBackoff
+const (
+	minFailureBackoff = time.Hour
+	maxFailureBackoff = 7 * 24 * time.Hour
+)
+
+// A product that could not be scraped or exported
+type failedProduct struct {
+	Link        string    `json:"link"`
+	Title       string    `json:"title,omitempty"`
+	ReleaseDate string    `json:"release_date,omitempty"`
+	Class       string    `json:"class"`
+	Error       string    `json:"error"`
+	Attempts    int       `json:"attempts"`
+	FirstFailed time.Time `json:"first_failed"`
+	LastFailed  time.Time `json:"last_failed"`
+	NextAttempt time.Time `json:"next_attempt"`
+}
+
+type failureQueue struct {
+	Path     string
+	Products []failedProduct
+}
+
+// Broad category of an error, to tell at a glance what went wrong
+func errorClass(err error) string {
+	var scryfallErr *scryfall.Error
+	var urlErr *url.Error
+	switch {
+	case errors.As(err, &scryfallErr):
+		return "scryfall"
+	case errors.Is(err, errResponseTooLarge):
+		return "too_large"
+	case errors.As(err, &urlErr):
+		return "network"
+	}
+	return "scrape"
+}
+
+func failureBackoff(attempts int) time.Duration {
+	backoff := minFailureBackoff
+	for i := 1; i < attempts && backoff < maxFailureBackoff; i++ {
+		backoff *= 2
+	}
+	return min(backoff, maxFailureBackoff)
+}
+
+func loadFailureQueue(path string) (*failureQueue, error) {
+	queue := &failureQueue{Path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &queue.Products)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// Record a failure of the product, adding it to the queue if needed
+func (queue *failureQueue) Add(link, title, releaseDate string, class string, err error) {
+	now := time.Now().UTC()
+
+	i := slices.IndexFunc(queue.Products, func(product failedProduct) bool {
+		return product.Link == link
+	})
+	if i < 0 {
+		queue.Products = append(queue.Products, failedProduct{
+			Link:        link,
+			FirstFailed: now,
+		})
+		i = len(queue.Products) - 1
+	}
+
+	product := &queue.Products[i]
+	if title != "" {
+		product.Title = title
+	}
+	if releaseDate != "" {
+		product.ReleaseDate = releaseDate
+	}
+	product.Class = class
+	product.Error = err.Error()
+	product.Attempts++
+	product.LastFailed = now
+	product.NextAttempt = now.Add(failureBackoff(product.Attempts))
+}
+
+func (queue *failureQueue) Remove(link string) {
+	queue.Products = slices.DeleteFunc(queue.Products, func(product failedProduct) bool {
+		return product.Link == link
+	})
+}
+
+func (queue *failureQueue) Save() error {
+	data, err := json.MarshalIndent(queue.Products, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(queue.Path, data, 0644)
+}
+
+// Scrape again the products of the queue whose backoff expired, or all of
+// them if force is set, and return how many succeeded
+func retryFailed(path string, force bool, opts crawlOptions) (int, error) {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	queue, err := loadFailureQueue(path)
+	if err != nil {
+		return 0, err
+	}
+
+	_, err = opts.Scraper.loadHeaders(opts.context())
+	if err != nil {
+		return 0, errors.New("unable to query scryfall")
+	}
+
+	resolved := 0
+	for _, product := range slices.Clone(queue.Products) {
+		if !force && time.Now().Before(product.NextAttempt) {
+			log.Println(product.Link, "will be retried after", product.NextAttempt.Local().Format(time.DateTime))
+			continue
+		}
+
+		result, err := opts.Scraper.scrapeProduct(product.Link, product.ReleaseDate)
+		if err != nil {
+			log.Println(product.Link, "-", err)
+			queue.Add(product.Link, "", "", errorClass(err), err)
+			continue
+		}
+		cardSet := result.CardSet
+
+		if product.ReleaseDate != "" {
+			cardSet.ReleaseDate = product.ReleaseDate
+		}
+		written, err := exportCardSet(cardSet, cardSet.Filename, opts)
+		if err != nil {
+			log.Println(err)
+			queue.Add(product.Link, "", "", "export", err)
+			continue
+		}
+		// Held drops stay queued until their changes are accepted
+		if written == fileHeld {
+			queue.Add(product.Link, "", "", "held", errHeld)
+			continue
+		}
+		queue.Remove(product.Link)
+		resolved++
+	}
+
+	return resolved, queue.Save()
+}
+
+func runRetryFailed(args []string) int {
+	fs := flag.NewFlagSet("retry-failed", flag.ExitOnError)
+	doOCROpt := fs.Bool("ocr", false, "Use OCR for the drops that are unknown to Scryfall")
+	allOpt := fs.Bool("all", false, "Retry every product, even the ones still backing off")
+	headersCacheOpt := fs.String("headers-cache", defaultHeadersCachePath(), "File where the Scryfall set headers are persisted between runs, empty to disable")
+	headersTTLOpt := fs.Duration("headers-ttl", defaultHeadersTTL, "How long the persisted Scryfall set headers are considered fresh")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader retry-failed [-ocr] [-all] failed.json")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	scraper := newScraper(*headersCacheOpt, *headersTTLOpt)
+	scraper.OCR = *doOCROpt
+	opts := crawlOptions{
+		Scraper: scraper,
+		Format:  outputFormats["txt"],
+		Layout:  layoutFlat,
+	}
+	resolved, err := retryFailed(paths[0], *allOpt, opts)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println(resolved, "failed products scraped")
+	return 0
+}
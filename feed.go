@@ -0,0 +1,239 @@
+package sldownloader
+
+import (
+	"encoding/xml"
+	"errors"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	feedTitle       = "Secret Lair Drops"
+	feedDescription = "Card lists of the Secret Lair drops found by sldownloader"
+	feedLink        = "https://secretlair.wizards.com"
+
+	// How many entries are kept in the feed across runs
+	maxFeedItems = 100
+)
+
+type rssFeed struct {
+	XMLName xml.Name   `xml:"rss"`
+	Version string     `xml:"version,attr"`
+	Channel rssChannel `xml:"channel"`
+}
+
+type rssChannel struct {
+	Title         string    `xml:"title"`
+	Link          string    `xml:"link"`
+	Description   string    `xml:"description"`
+	LastBuildDate string    `xml:"lastBuildDate"`
+	Items         []rssItem `xml:"item"`
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link"`
+	GUID        string `xml:"guid"`
+	PubDate     string `xml:"pubDate,omitempty"`
+	Description string `xml:"description"`
+}
+
+type atomFeed struct {
+	XMLName xml.Name    `xml:"http://www.w3.org/2005/Atom feed"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Link    atomLink    `xml:"link"`
+	Updated string      `xml:"updated"`
+	Content atomContent `xml:"content"`
+}
+
+type atomContent struct {
+	Type string `xml:"type,attr"`
+	Body string `xml:",chardata"`
+}
+
+// A format-agnostic feed entry, used to merge new drops with the existing ones
+type feedEntry struct {
+	Title   string
+	Link    string
+	Date    time.Time
+	Content string
+}
+
+func newFeedEntry(cardSet *CardSet) feedEntry {
+	var lines []string
+	for _, card := range cardSet.Cards {
+		lines = append(lines, formatCard(card))
+	}
+
+	date, err := time.Parse("2006-01-02", cardSet.ReleaseDate)
+	if err != nil {
+		date = time.Now().UTC()
+	}
+
+	content := strings.Join(lines, "\n")
+	if cardSet.Description != "" {
+		content = cardSet.Description + "\n\n" + content
+	}
+
+	return feedEntry{
+		Title:   cardSet.Title,
+		Link:    cardSet.Link,
+		Date:    date,
+		Content: content,
+	}
+}
+
+// Add the input card sets to the feed at the given path, preserving any entry
+// already present, and rewrite it in its format (Atom for .atom files, RSS otherwise)
+func updateFeed(path string, cardSets []*CardSet) error {
+	isAtom := strings.HasSuffix(path, ".atom")
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	var oldEntries []feedEntry
+	if len(data) > 0 {
+		if isAtom {
+			oldEntries, err = parseAtom(data)
+		} else {
+			oldEntries, err = parseRSS(data)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	// Newest entries go first, replacing any older entry with the same link
+	var entries []feedEntry
+	seen := map[string]bool{}
+	for i := len(cardSets) - 1; i >= 0; i-- {
+		entry := newFeedEntry(cardSets[i])
+		if seen[entry.Link] {
+			continue
+		}
+		seen[entry.Link] = true
+		entries = append(entries, entry)
+	}
+	for _, entry := range oldEntries {
+		if seen[entry.Link] {
+			continue
+		}
+		seen[entry.Link] = true
+		entries = append(entries, entry)
+	}
+	if len(entries) > maxFeedItems {
+		entries = entries[:maxFeedItems]
+	}
+
+	if isAtom {
+		data, err = buildAtom(entries)
+	} else {
+		data, err = buildRSS(entries)
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), data...), 0644)
+}
+
+func parseRSS(data []byte) ([]feedEntry, error) {
+	var feed rssFeed
+	err := xml.Unmarshal(data, &feed)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []feedEntry
+	for _, item := range feed.Channel.Items {
+		date, _ := time.Parse(time.RFC1123Z, item.PubDate)
+		entries = append(entries, feedEntry{
+			Title:   item.Title,
+			Link:    item.Link,
+			Date:    date,
+			Content: item.Description,
+		})
+	}
+	return entries, nil
+}
+
+func parseAtom(data []byte) ([]feedEntry, error) {
+	var feed atomFeed
+	err := xml.Unmarshal(data, &feed)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []feedEntry
+	for _, entry := range feed.Entries {
+		date, _ := time.Parse(time.RFC3339, entry.Updated)
+		entries = append(entries, feedEntry{
+			Title:   entry.Title,
+			Link:    entry.Link.Href,
+			Date:    date,
+			Content: entry.Content.Body,
+		})
+	}
+	return entries, nil
+}
+
+func buildRSS(entries []feedEntry) ([]byte, error) {
+	feed := rssFeed{
+		Version: "2.0",
+		Channel: rssChannel{
+			Title:         feedTitle,
+			Link:          feedLink,
+			Description:   feedDescription,
+			LastBuildDate: time.Now().UTC().Format(time.RFC1123Z),
+		},
+	}
+	for _, entry := range entries {
+		feed.Channel.Items = append(feed.Channel.Items, rssItem{
+			Title:       entry.Title,
+			Link:        entry.Link,
+			GUID:        entry.Link,
+			PubDate:     entry.Date.Format(time.RFC1123Z),
+			Description: entry.Content,
+		})
+	}
+	return xml.MarshalIndent(feed, "", "  ")
+}
+
+func buildAtom(entries []feedEntry) ([]byte, error) {
+	feed := atomFeed{
+		Title:   feedTitle,
+		ID:      feedLink,
+		Link:    atomLink{Href: feedLink},
+		Updated: time.Now().UTC().Format(time.RFC3339),
+	}
+	for _, entry := range entries {
+		feed.Entries = append(feed.Entries, atomEntry{
+			Title:   entry.Title,
+			ID:      entry.Link,
+			Link:    atomLink{Href: entry.Link},
+			Updated: entry.Date.Format(time.RFC3339),
+			Content: atomContent{
+				Type: "text",
+				Body: entry.Content,
+			},
+		})
+	}
+	return xml.MarshalIndent(feed, "", "  ")
+}
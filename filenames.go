@@ -0,0 +1,113 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+// Output names of the drops exported so far, so that two drops whose titles
+// clean up to the same name don't overwrite each other
+type filenameRegistry struct {
+	// Link of the drop owning each name during this run
+	owners map[string]string
+	// Extension of the existing files
+	ext string
+
+	// Where the name of every drop, by link, is recorded if set
+	Path    string
+	Mapping map[string]string
+}
+
+func loadFilenameRegistry(path, ext string) (*filenameRegistry, error) {
+	registry := &filenameRegistry{
+		owners:  map[string]string{},
+		ext:     ext,
+		Path:    path,
+		Mapping: map[string]string{},
+	}
+	if path == "" {
+		return registry, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return registry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &registry.Mapping)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return registry, nil
+}
+
+// Link of the drop saved with the given name and extension, empty if there
+// is none
+func fileOwner(name, ext string) string {
+	cardSet, err := loadCardSet(name + ext)
+	if errors.Is(err, fs.ErrNotExist) {
+		return ""
+	}
+	if err != nil || cardSet.Link == "" {
+		// Better not to overwrite what can't be identified
+		return "unknown"
+	}
+	return cardSet.Link
+}
+
+// Append the suffix to the name, or to its directory in the drop layout
+func suffixFilename(name, suffix string) string {
+	if filepath.Base(name) == "cards" {
+		return filepath.Join(filepath.Dir(name)+" ("+suffix+")", "cards")
+	}
+	return name + " (" + suffix + ")"
+}
+
+// Return the name the drop is exported with: the given one unless another
+// drop already uses it, in this run or in the existing files, in which case
+// it is suffixed with the release date, or else the product id
+func (registry *filenameRegistry) Resolve(name, link, releaseDate string) string {
+	candidates := []string{name}
+	if releaseDate != "" {
+		candidates = append(candidates, suffixFilename(name, releaseDate))
+	}
+	candidates = append(candidates, suffixFilename(name, productKey(link)))
+
+	resolved := candidates[len(candidates)-1]
+	for _, candidate := range candidates {
+		owner, found := registry.owners[candidate]
+		if !found {
+			owner = fileOwner(candidate, registry.ext)
+		}
+		// Links of older files may differ in the locale or the slug
+		if owner == "" || productKey(owner) == productKey(link) {
+			resolved = candidate
+			break
+		}
+	}
+
+	if resolved != name {
+		log.Printf("'%s' is already used by another drop, saving as '%s'", name, resolved)
+	}
+	registry.owners[resolved] = link
+	registry.Mapping[link] = resolved
+	return resolved
+}
+
+func (registry *filenameRegistry) Save() error {
+	if registry.Path == "" {
+		return nil
+	}
+	data, err := json.MarshalIndent(registry.Mapping, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(registry.Path, data, 0644)
+}
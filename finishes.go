@@ -0,0 +1,44 @@
+package sldownloader
+
+// Finishes in the order their files are split, the first one present keeps
+// the name of the drop and the others are suffixed
+var splitFinishOrder = []struct {
+	Finish string
+	Suffix string
+}{
+	{"nonfoil", ""},
+	{"foil", " Foil"},
+	{"etched", " Etched"},
+}
+
+// Split the cards of a drop by finish into sets of their own, for the drops
+// exported with one file per finish, returning the suffix of the file of each
+func splitFinishes(cardSet *CardSet) ([]*CardSet, []string) {
+	var parts []*CardSet
+	var suffixes []string
+	for _, finish := range splitFinishOrder {
+		part := *cardSet
+		part.Cards = nil
+		for _, card := range cardSet.Cards {
+			if cardFinish(card) == finish.Finish {
+				part.Cards = append(part.Cards, card)
+			}
+		}
+		if len(part.Cards) == 0 {
+			continue
+		}
+
+		suffix := finish.Suffix
+		if len(parts) == 0 {
+			suffix = ""
+		}
+		part.Title += suffix
+		part.Filename += suffix
+		parts = append(parts, &part)
+		suffixes = append(suffixes, suffix)
+	}
+	if len(parts) == 0 {
+		return []*CardSet{cardSet}, []string{""}
+	}
+	return parts, suffixes
+}
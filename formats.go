@@ -0,0 +1,174 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+)
+
+// An output format for card sets, along with how to read it back
+type outputFormat struct {
+	Ext   string
+	Write func(w io.Writer, cardSet *CardSet) error
+	Read  func(r io.Reader) (*CardSet, error)
+	// Whether the files can't be read back as they were written. Card lines
+	// written with a custom format may still parse, but into other cards.
+	Lossy bool
+}
+
+var outputFormats = map[string]outputFormat{
+	"txt": {
+		Ext:   ".txt",
+		Write: writeTxt,
+		Read:  ParseCardSet,
+	},
+	"json": {
+		Ext:   ".json",
+		Write: writeJSON,
+		Read:  readJSON,
+	},
+}
+
+var errNotCardSet = errors.New("not a card set")
+
+// The txt format with the card lines written by a template, such as
+// "{{.Count}}x {{.Name}} ({{.Set}}) {{.Number}}", instead of formatCard.
+// Cards are passed with Set always filled in.
+func txtLineFormat(line string) (outputFormat, error) {
+	tmpl, err := template.New("line").Funcs(template.FuncMap{
+		"lower": strings.ToLower,
+		"upper": strings.ToUpper,
+	}).Option("missingkey=error").Parse(line)
+	if err != nil {
+		return outputFormat{}, err
+	}
+
+	format := outputFormats["txt"]
+	format.Write = func(w io.Writer, cardSet *CardSet) error {
+		return writeTxtLines(w, cardSet, tmpl)
+	}
+	format.Lossy = true
+	return format, nil
+}
+
+// Format a card line with the template, or formatCard when nil
+func formatTxtLine(card CardData, tmpl *template.Template) (string, error) {
+	line := formatCard(card)
+	if tmpl != nil {
+		if card.Set == "" {
+			card.Set = "SLD"
+		}
+		var sb strings.Builder
+		err := tmpl.Execute(&sb, card)
+		if err != nil {
+			return "", err
+		}
+		line = sb.String()
+	}
+	if comment := cardComment(card); comment != "" {
+		line += " # " + comment
+	}
+	return line, nil
+}
+
+// Write the card set in the magic-preconstructed-decks format
+func writeTxt(w io.Writer, cardSet *CardSet) error {
+	return writeTxtLines(w, cardSet, nil)
+}
+
+// Write the card set in the txt format, with the card lines written by the
+// template when not nil
+func writeTxtLines(w io.Writer, cardSet *CardSet, tmpl *template.Template) error {
+	fmt.Fprintf(w, "// NAME: %s\n", cardSet.Title)
+	fmt.Fprintf(w, "// SOURCE: %s\n", cardSet.Link)
+	if cardSet.ReleaseDate != "" {
+		fmt.Fprintf(w, "// DATE: %s\n", cardSet.ReleaseDate)
+	}
+	if cardSet.Category != "" {
+		fmt.Fprintf(w, "// CATEGORY: %s\n", cardSet.Category)
+	}
+	if cardSet.Note != "" {
+		fmt.Fprintf(w, "// NOTE: %s\n", strings.Join(strings.Fields(cardSet.Note), " "))
+	}
+	if cardSet.Sequence > 0 {
+		fmt.Fprintf(w, "// SEQUENCE: %d\n", cardSet.Sequence)
+	}
+	if cardSet.Status != "" {
+		fmt.Fprintf(w, "// STATUS: %s\n", cardSet.Status)
+	}
+	for _, card := range cardSet.Cards {
+		line, err := formatTxtLine(card, tmpl)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, line)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeJSON(w io.Writer, cardSet *CardSet) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cardSet)
+}
+
+// Write the card set to filename with a .json extension, or stdout if empty
+func writeJSONFile(cardSet *CardSet, filename string) error {
+	if filename == "" {
+		return writeJSON(os.Stdout, cardSet)
+	}
+
+	file, err := os.Create(filename + ".json")
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	err = writeJSON(file, cardSet)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+func readJSON(r io.Reader) (*CardSet, error) {
+	var cardSet CardSet
+	err := json.NewDecoder(r).Decode(&cardSet)
+	if err != nil {
+		return nil, err
+	}
+	if cardSet.Link == "" && cardSet.Title == "" {
+		return nil, errNotCardSet
+	}
+	return &cardSet, nil
+}
+
+// Whether the JSON file holds a card set, rather than any other state
+func isJSONCardSet(path string) bool {
+	file, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+	_, err = readJSON(file)
+	return !errors.Is(err, errNotCardSet) && !errors.As(err, new(*json.UnmarshalTypeError))
+}
+
+// The format of a file from its extension, txt when unknown
+func formatOf(path string) outputFormat {
+	ext := filepath.Ext(path)
+	for _, format := range outputFormats {
+		if format.Ext == ext {
+			return format
+		}
+	}
+	return outputFormats["txt"]
+}
@@ -0,0 +1,134 @@
+package sldownloader
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Image names and alt texts marking the back of a card, such as
+// sol-ring-back.png, 12_b.jpg or "Sol Ring (back)", without catching card
+// names such as Back to Basics
+var (
+	backImageRegex = regexp.MustCompile(`(?i)[_-](back|rear|reverse)(side)?([_-]?\d+)?\.[a-z]+$|[_-]b\.[a-z]+$`)
+	backAltRegex   = regexp.MustCompile(`(?i)(^|[\s(–-])back( side| face)?\)?$|\bback of\b|\breverse side\b`)
+)
+
+// Whether a gallery entry shows the back of a card, from its link or alt text
+func isBackImage(s *goquery.Selection) bool {
+	link, _ := s.Attr("href")
+	if backImageRegex.MatchString(strings.SplitN(link, "?", 2)[0]) {
+		return true
+	}
+	alt := strings.TrimSpace(s.Find("img").AttrOr("alt", ""))
+	return backAltRegex.MatchString(alt)
+}
+
+// The gallery entries of a card, Back is nil for single-faced cards
+type galleryPair struct {
+	Front *goquery.Selection
+	Back  *goquery.Selection
+}
+
+// Pair the gallery entries of each card, leaving out the packaging shots.
+// Backs are detected for each image and follow their front, and when no image
+// can be told apart a gallery announcing twice as many images as cards is
+// split in pairs.
+func galleryPairs(doc *goquery.Document, cards int) []galleryPair {
+	var all []*goquery.Selection
+	var pairs []galleryPair
+	doc.Find(`figure a`).Each(func(_ int, s *goquery.Selection) {
+		if isPackagingImage(s) {
+			return
+		}
+		all = append(all, s)
+		if !isBackImage(s) {
+			pairs = append(pairs, galleryPair{Front: s})
+			return
+		}
+		// A back without a front right before it can't be paired
+		if len(pairs) > 0 && pairs[len(pairs)-1].Back == nil && all[len(all)-2] == pairs[len(pairs)-1].Front {
+			pairs[len(pairs)-1].Back = s
+		}
+	})
+	if len(pairs) < len(all) {
+		return pairs
+	}
+
+	galleryTitle := doc.Find(`h2[class="pdp_title"]`).Text()
+	if strings.Contains(galleryTitle, " (") {
+		fields := strings.Fields(galleryTitle)
+		expectedNum := fields[len(fields)-1]
+		expectedNum = strings.TrimLeft(expectedNum, "(")
+		expectedNum = strings.TrimRight(expectedNum, ")")
+		expectedNumber, _ := strconv.Atoi(expectedNum)
+		if expectedNumber/2 == cards {
+			pairs = nil
+			for i := 0; i < len(all); i += 2 {
+				pair := galleryPair{Front: all[i]}
+				if i+1 < len(all) {
+					pair.Back = all[i+1]
+				}
+				pairs = append(pairs, pair)
+			}
+		}
+	}
+	return pairs
+}
+
+// Return the gallery entries showing the front of each card
+func galleryFronts(doc *goquery.Document, cards int) []*goquery.Selection {
+	var fronts []*goquery.Selection
+	for _, pair := range galleryPairs(doc, cards) {
+		fronts = append(fronts, pair.Front)
+	}
+	return fronts
+}
+
+// Look up which card of the list an OCR'd number belongs to, for galleries
+// whose order differs from the list. Only cards still without a number are
+// considered.
+func (s *Scraper) numberOwner(ctx context.Context, cards []CardData, num, lang string) (int, CardData, error) {
+	var res []CardData
+	err := s.retry("validation", func() (err error) {
+		res, err = search(ctx, languageQuery(fmt.Sprintf("e:sld cn:\"%s\"", num), lang))
+		return err
+	})
+	if err != nil || len(res) == 0 {
+		return -1, CardData{}, err
+	}
+
+	for j, card := range cards {
+		if card.Number == "" && strings.EqualFold(card.Name, res[0].Name) {
+			return j, res[0], nil
+		}
+	}
+	return -1, CardData{}, nil
+}
+
+// Links to the images of a card, Back is empty for single-faced cards
+type imagePair struct {
+	Front string
+	Back  string
+}
+
+// Resolve the links of the gallery pairs, skipping the entries without one
+func pairImageLinks(pairs []galleryPair) []imagePair {
+	var links []imagePair
+	for _, pair := range pairs {
+		front, found := galleryImageLink(pair.Front)
+		if !found {
+			continue
+		}
+		link := imagePair{Front: front}
+		if pair.Back != nil {
+			link.Back, _ = galleryImageLink(pair.Back)
+		}
+		links = append(links, link)
+	}
+	return links
+}
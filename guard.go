@@ -0,0 +1,111 @@
+package sldownloader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"strings"
+)
+
+// Why a drop kept back by the guard isn't done yet
+var errHeld = errors.New("the new version changes the cards, see the .new files or use -accept-changes")
+
+// Changes of the cards of an exported drop that may come from a regression
+// of the scraper rather than from the store: cards added or removed, or
+// numbers changed or lost. Numbers found for cards that had none are not.
+func regressions(oldSet, newSet *CardSet) dropDiff {
+	diff := diffCardSets(oldSet, newSet)
+	var renumbered []renumbered
+	for _, change := range diff.Renumbered {
+		if change.OldNumber != "" {
+			renumbered = append(renumbered, change)
+		}
+	}
+	diff.Renumbered = renumbered
+	return diff
+}
+
+// Describe the changes, one card per line as in the diff subcommand
+func (d dropDiff) String() string {
+	var lines []string
+	for _, card := range d.Removed {
+		lines = append(lines, "- "+formatCard(card))
+	}
+	for _, card := range d.Added {
+		lines = append(lines, "+ "+formatCard(card))
+	}
+	for _, change := range d.Renumbered {
+		lines = append(lines, fmt.Sprintf("~ %s (was %s)", formatCard(change.Card), change.OldNumber))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// Changes of the file at path that the new content of its part of the drop
+// would bring, nil when there are none or the file doesn't exist. Files that
+// can't be read back, such as the ones written with -line-format, are
+// changed unless their content stays the same.
+func fileRegressions(path string, part *CardSet, content []byte, format outputFormat) *dropDiff {
+	old, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err == nil && normalizeContent(old) == normalizeContent(content) {
+		return nil
+	}
+	if err == nil && format.Lossy {
+		err = errors.New("written with -line-format")
+	}
+	var oldSet *CardSet
+	if err == nil {
+		oldSet, err = format.Read(bytes.NewReader(old))
+	}
+	if err != nil {
+		log.Printf("%s: %v, it can't be compared with the new version", path, err)
+		return &dropDiff{Filename: path, Status: "unreadable"}
+	}
+
+	diff := regressions(oldSet, part)
+	if diff.empty() {
+		return nil
+	}
+	return &diff
+}
+
+// Keep the exported files of the drop when the new version changes their
+// cards, writing the new versions next to them with a .new extension
+// instead. Each part of a split drop is compared with its own file. Return
+// whether the files were kept.
+func holdRegressions(cardSet *CardSet, parts []*CardSet, filenames []string, format outputFormat) (bool, error) {
+	var held *dropDiff
+	for i, part := range parts {
+		path := filenames[i] + format.Ext
+		var buf bytes.Buffer
+		err := format.Write(&buf, part)
+		if err != nil {
+			return false, err
+		}
+
+		diff := fileRegressions(path, part, buf.Bytes(), format)
+		if diff == nil {
+			continue
+		}
+		err = os.WriteFile(path+".new", buf.Bytes(), 0644)
+		if err != nil {
+			return false, err
+		}
+		log.Printf("Kept '%s', the new version changes its cards, see '%s.new' or use -accept-changes:\n%s",
+			path, path, diff)
+
+		if held == nil {
+			held = &dropDiff{Filename: cardSet.Filename, Status: diff.Status}
+		}
+		held.Added = append(held.Added, diff.Added...)
+		held.Removed = append(held.Removed, diff.Removed...)
+		held.Renumbered = append(held.Renumbered, diff.Renumbered...)
+	}
+	cardSet.held = held
+	return held != nil, nil
+}
@@ -0,0 +1,47 @@
+package sldownloader
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestExportSplitDropNotHeld(t *testing.T) {
+	cardSet := CardSet{
+		Title: "Bob Ross: Happy Little Gathering",
+		Link:  "https://secretlair.wizards.com/us/product/1",
+		Cards: []CardData{
+			{Name: "Plains", Number: "1", Count: 1},
+			{Name: "Plains", Number: "1", Count: 1, Foil: true},
+			{Name: "Treasure", Number: "2", Count: 1, Token: true},
+		},
+	}
+	opts := crawlOptions{Format: outputFormats["txt"], SplitTokens: true, SplitFinishes: true}
+	path := filepath.Join(t.TempDir(), "drop")
+
+	for _, want := range []writeResult{fileCreated, fileUnchanged} {
+		again := cardSet
+		again.Cards = slices.Clone(cardSet.Cards)
+		got, err := exportCardSet(&again, path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("got %s, want %s", got, want)
+		}
+	}
+
+	// A file that can't be read back is kept
+	err := os.WriteFile(path+" Tokens.txt", []byte("not a card\n"), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := exportCardSet(&cardSet, path, opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != fileHeld {
+		t.Errorf("got %s, want %s", got, fileHeld)
+	}
+}
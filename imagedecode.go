@@ -0,0 +1,172 @@
+package sldownloader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"golang.org/x/image/webp"
+)
+
+// Formats the image CDN is asked for, leaving out AVIF which is slower to
+// decode
+const acceptImages = "image/png,image/jpeg,image/webp;q=0.9,image/*;q=0.5"
+
+// Command of libavif decoding AVIF images to PNG, there is no decoder in Go
+const avifDecoder = "avifdec"
+
+var (
+	errUnsupportedImage = errors.New("AVIF images need " + avifDecoder + " (libavif) to be decoded")
+	errUndecodableImage = errors.New("undecodable image")
+)
+
+// Decode an AVIF image by converting it to PNG with avifdec. It's not
+// registered with the image package, which would run avifdec for any program
+// importing this one.
+func decodeAVIF(r io.Reader) (image.Image, error) {
+	path, err := exec.LookPath(avifDecoder)
+	if err != nil {
+		return nil, errUnsupportedImage
+	}
+
+	dir, err := os.MkdirTemp("", "sldownloader-avif")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	in, out := filepath.Join(dir, "in.avif"), filepath.Join(dir, "out.png")
+	err = os.WriteFile(in, data, 0644)
+	if err != nil {
+		return nil, err
+	}
+	msg, err := exec.Command(path, in, out).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %v %s", avifDecoder, err, bytes.TrimSpace(msg))
+	}
+
+	converted, err := os.ReadFile(out)
+	if err != nil {
+		return nil, err
+	}
+	return png.Decode(bytes.NewReader(converted))
+}
+
+// Whether the data is an AVIF image, from the brand of its ISO-BMFF header
+func isAVIF(data []byte) bool {
+	if len(data) < 12 || string(data[4:8]) != "ftyp" {
+		return false
+	}
+	brand := string(data[8:12])
+	return brand == "avif" || brand == "avis"
+}
+
+// Width and height of an AVIF image, read from the ispe properties of its
+// header rather than decoding it. The largest one is the primary image, the
+// others being thumbnails or the tiles of a grid.
+func avifSize(data []byte) (width, height int, ok bool) {
+	meta, found := findBox(data, "meta")
+	// The meta box has a version and flags before its children
+	if !found || len(meta) < 4 {
+		return 0, 0, false
+	}
+	iprp, found := findBox(meta[4:], "iprp")
+	if !found {
+		return 0, 0, false
+	}
+	ipco, found := findBox(iprp, "ipco")
+	if !found {
+		return 0, 0, false
+	}
+
+	for len(ipco) > 0 {
+		typ, body, rest, found := nextBox(ipco)
+		if !found {
+			break
+		}
+		if typ == "ispe" && len(body) >= 12 {
+			w := int(binary.BigEndian.Uint32(body[4:8]))
+			h := int(binary.BigEndian.Uint32(body[8:12]))
+			if w*h > width*height {
+				width, height, ok = w, h, true
+			}
+		}
+		ipco = rest
+	}
+	return width, height, ok
+}
+
+// Body of the first ISO-BMFF box of the given type among the ones in data
+func findBox(data []byte, typ string) ([]byte, bool) {
+	for len(data) > 0 {
+		boxType, body, rest, found := nextBox(data)
+		if !found {
+			return nil, false
+		}
+		if boxType == typ {
+			return body, true
+		}
+		data = rest
+	}
+	return nil, false
+}
+
+// Split the ISO-BMFF box at the start of data from the ones after it
+func nextBox(data []byte) (typ string, body, rest []byte, ok bool) {
+	if len(data) < 8 {
+		return "", nil, nil, false
+	}
+	size, header := uint64(binary.BigEndian.Uint32(data)), uint64(8)
+	switch size {
+	case 0:
+		// The box extends to the end of the data
+		size = uint64(len(data))
+	case 1:
+		if len(data) < 16 {
+			return "", nil, nil, false
+		}
+		size, header = binary.BigEndian.Uint64(data[8:16]), 16
+	}
+	if size < header || size > uint64(len(data)) {
+		return "", nil, nil, false
+	}
+	return string(data[4:8]), data[header:size], data[size:], true
+}
+
+// Convert the image to PNG when tesseract can't read its format
+func ocrImage(data []byte) ([]byte, error) {
+	var img image.Image
+	var err error
+	switch {
+	case isAVIF(data):
+		img, err = decodeAVIF(bytes.NewReader(data))
+		if errors.Is(err, errUnsupportedImage) {
+			return nil, err
+		}
+	case http.DetectContentType(data) == "image/webp":
+		img, err = webp.Decode(bytes.NewReader(data))
+	default:
+		return data, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", errUndecodableImage, err)
+	}
+	var buf bytes.Buffer
+	err = png.Encode(&buf, img)
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
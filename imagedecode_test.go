@@ -0,0 +1,39 @@
+package sldownloader
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func isobmffBox(typ string, body ...[]byte) []byte {
+	var data []byte
+	for _, b := range body {
+		data = append(data, b...)
+	}
+	header := binary.BigEndian.AppendUint32(nil, uint32(8+len(data)))
+	return append(append(header, typ...), data...)
+}
+
+func ispeBox(width, height uint32) []byte {
+	body := binary.BigEndian.AppendUint32(make([]byte, 4), width)
+	return isobmffBox("ispe", binary.BigEndian.AppendUint32(body, height))
+}
+
+func TestAVIFSize(t *testing.T) {
+	data := append(isobmffBox("ftyp", []byte("avif"), make([]byte, 4)),
+		isobmffBox("meta", make([]byte, 4), isobmffBox("hdlr", make([]byte, 24)),
+			isobmffBox("iprp", isobmffBox("ipco", ispeBox(160, 224), ispeBox(630, 880), isobmffBox("pixi", make([]byte, 8)))))...)
+	if !isAVIF(data) {
+		t.Fatal("not recognized as AVIF")
+	}
+
+	width, height, ok := avifSize(data)
+	if !ok || width != 630 || height != 880 {
+		t.Errorf("got %dx%d (%v), want 630x880", width, height, ok)
+	}
+
+	_, _, ok = avifSize(data[:40])
+	if ok {
+		t.Error("size found in a truncated header")
+	}
+}
@@ -0,0 +1,114 @@
+package sldownloader
+
+import (
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Query parameters the image CDNs use to serve resized variants
+var resizeParams = []string{"w", "h", "width", "height", "size", "resize", "fit", "crop", "quality", "q", "dpr", "fm", "format"}
+
+// Resizing options embedded in the path, as in /cdn-cgi/image/width=600/ or
+// the _600x600 suffix before the extension
+var (
+	cdnResizePath   = regexp.MustCompile(`/cdn-cgi/image/[^/]+/`)
+	sizeSuffixRegex = regexp.MustCompile(`_\d+x\d*(\.[A-Za-z]+)$`)
+)
+
+// Rewrite a link to a resized image so that it points to the original
+func originalImageLink(link string) string {
+	u, err := url.Parse(link)
+	if err != nil {
+		return link
+	}
+
+	u.Path = cdnResizePath.ReplaceAllString(u.Path, "/")
+	u.Path = sizeSuffixRegex.ReplaceAllString(u.Path, "$1")
+
+	query := u.Query()
+	for _, param := range resizeParams {
+		query.Del(param)
+	}
+	u.RawQuery = query.Encode()
+
+	return u.String()
+}
+
+// Return the widest candidate of a srcset attribute, if any
+func widestSrcset(srcset string) string {
+	best := ""
+	bestWidth := 0.0
+	for _, candidate := range strings.Split(srcset, ",") {
+		fields := strings.Fields(candidate)
+		if len(fields) == 0 {
+			continue
+		}
+		width := 1.0
+		if len(fields) > 1 {
+			descriptor := fields[1]
+			value, err := strconv.ParseFloat(descriptor[:len(descriptor)-1], 64)
+			if err == nil {
+				width = value
+			}
+		}
+		if best == "" || width > bestWidth {
+			best = fields[0]
+			bestWidth = width
+		}
+	}
+	return best
+}
+
+// A collector number introduced as such in a text, as in "#123", "No. 123"
+// or "SLD 123", so that the digits of card names aren't taken for one
+var captionNumberRegex = regexp.MustCompile(`(?i)(?:#|\bno\.?|\bnumber|\bsld\b[ :#-]*|\bcn\b:?)\s*(\d{1,4}[ab]?)\b`)
+
+// Collector number given by the alt text, title or caption of a gallery
+// entry, empty when none tells
+func galleryTextNumber(s *goquery.Selection) string {
+	img := s.Find("img")
+	texts := []string{
+		img.AttrOr("alt", ""),
+		img.AttrOr("title", ""),
+		s.AttrOr("title", ""),
+		s.AttrOr("data-caption", ""),
+		s.Closest("figure").Find("figcaption").Text(),
+	}
+	for _, text := range texts {
+		match := captionNumberRegex.FindStringSubmatch(text)
+		if match != nil {
+			return normalizeNumber(match[1])
+		}
+	}
+	return ""
+}
+
+// Link to the highest resolution version of a gallery image, from the widest
+// srcset candidate when available, or the link of the gallery entry
+func galleryImageLink(s *goquery.Selection) (string, bool) {
+	link, found := s.Attr("href")
+
+	img := s.Find("img")
+	for _, attr := range []string{"srcset", "data-srcset"} {
+		srcset, ok := img.Attr(attr)
+		if ok && strings.TrimSpace(srcset) != "" {
+			link = widestSrcset(srcset)
+			found = true
+			break
+		}
+	}
+	if !found || link == "" {
+		return "", false
+	}
+
+	if strings.HasPrefix(link, "//") {
+		link = "https:" + link
+	} else if strings.HasPrefix(link, "/") {
+		link = storefrontURL + link
+	}
+	return originalImageLink(link), true
+}
@@ -0,0 +1,27 @@
+package sldownloader
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+func TestGalleryTextNumber(t *testing.T) {
+	html := `<a href="/1.jpg"><img alt="Sol Ring #0123"></a>
+<figure><a href="/2.jpg"><img alt="Lightning Bolt"></a><figcaption>Lightning Bolt - SLD 456</figcaption></figure>
+<a href="/3.jpg"><img alt="Delver of Secrets No. 12a"></a>
+<a href="/4.jpg"><img alt="Borrowing 100,000 Arrows"></a>
+<a href="/5.jpg" title="Extra Life 2024"><img></a>`
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(html))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{"123", "456", "12a", "", ""}
+	doc.Find("a").Each(func(i int, s *goquery.Selection) {
+		if got := galleryTextNumber(s); got != want[i] {
+			t.Errorf("image %d: got %q, want %q", i+1, got, want[i])
+		}
+	})
+}
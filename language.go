@@ -0,0 +1,62 @@
+package sldownloader
+
+import "strings"
+
+// Language tags of the txt format, along with the words marking a non-English
+// printing in the store listings and the code Scryfall uses for the language
+var languageTags = []struct {
+	Tag      string
+	Markers  []string
+	Scryfall string
+}{
+	{"jp", []string{"Japanese"}, "ja"},
+	{"it", []string{"Italian-language", "Italian Language"}, "it"},
+	{"de", []string{"German-language"}, "de"},
+	{"fr", []string{"French-language"}, "fr"},
+	{"es", []string{"Spanish-language"}, "es"},
+	{"pt", []string{"Portuguese-language"}, "pt"},
+	{"kr", []string{"Korean-language"}, "ko"},
+	{"ru", []string{"Russian-language"}, "ru"},
+	{"cs", []string{"Simplified Chinese"}, "zhs"},
+	{"ct", []string{"Traditional Chinese"}, "zht"},
+}
+
+// Return the language tag of a store line, empty for English
+func lineLanguage(line string) string {
+	for _, lang := range languageTags {
+		for _, marker := range lang.Markers {
+			if strings.Contains(line, marker) {
+				return lang.Tag
+			}
+		}
+	}
+	return ""
+}
+
+func isLanguageTag(tag string) bool {
+	for _, lang := range languageTags {
+		if lang.Tag == tag {
+			return true
+		}
+	}
+	return false
+}
+
+// Restrict a Scryfall query to the printings in the language of the tag,
+// which are otherwise left out of the results
+func languageQuery(query, tag string) string {
+	for _, lang := range languageTags {
+		if lang.Tag == tag {
+			return query + " lang:" + lang.Scryfall
+		}
+	}
+	return query
+}
+
+// Return the language tag of a whole product, empty for English
+func productLanguage(title string) string {
+	if productCategory(title) == "japanese" {
+		return "jp"
+	}
+	return ""
+}
@@ -0,0 +1,120 @@
+package sldownloader
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Directory where the drops are exported, and read back from
+const outputDir = "."
+
+const (
+	// Every drop is a txt file in the current directory
+	layoutFlat = "flat"
+	// Every drop is a directory holding its card list and related artifacts
+	layoutDrop = "drop"
+)
+
+// Output name, without extension, of a drop in the directory layout
+func dropOutputName(cardSet *CardSet) string {
+	dir := strings.TrimSpace(cardSet.ReleaseDate + " " + cardSet.Filename)
+	return filepath.Join(dir, "cards")
+}
+
+// Save the product page and its gallery images next to the card list, the
+// packaging shots in their own directory
+func writeDropArtifacts(dir string, cardSet *CardSet) error {
+	if len(cardSet.page) > 0 {
+		err := os.WriteFile(filepath.Join(dir, "page.html"), cardSet.page, 0644)
+		if err != nil {
+			return err
+		}
+	}
+
+	var images, names []string
+	for i, pair := range cardSet.images {
+		if pair.Back == "" {
+			images = append(images, pair.Front)
+			names = append(names, fmt.Sprintf("%02d", i+1))
+			continue
+		}
+		// Named like the faces of double-faced cards on Scryfall
+		images = append(images, pair.Front, pair.Back)
+		names = append(names, fmt.Sprintf("%02da", i+1), fmt.Sprintf("%02db", i+1))
+	}
+	err := saveImages(filepath.Join(dir, "images"), images, names)
+	if err != nil {
+		return err
+	}
+	return saveImages(filepath.Join(dir, "packaging"), cardSet.packaging, nil)
+}
+
+// Download the images to the directory with the given names, or numbered in
+// order when there are none
+func saveImages(imagesDir string, links, names []string) error {
+	if len(links) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(imagesDir, 0755)
+	if err != nil {
+		return err
+	}
+
+	for i, link := range links {
+		ext := path.Ext(strings.Split(link, "?")[0])
+		if ext == "" {
+			ext = ".jpg"
+		}
+		base := fmt.Sprintf("%02d", i+1)
+		if names != nil {
+			base = names[i]
+		}
+		name := filepath.Join(imagesDir, base+ext)
+
+		// Images don't change once published
+		_, err := os.Stat(name)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+
+		tmp, err := downloadImage(link)
+		if err != nil {
+			log.Println(link, err)
+			continue
+		}
+		err = moveFile(tmp, name)
+		if err != nil {
+			os.Remove(tmp)
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Rename the file, falling back to a copy when crossing filesystems
+func moveFile(src, dst string) error {
+	err := os.Rename(src, dst)
+	if err == nil {
+		return nil
+	}
+
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(dst, data, 0644)
+	if err != nil {
+		return err
+	}
+	return os.Remove(src)
+}
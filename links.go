@@ -0,0 +1,118 @@
+package sldownloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// A product link collected by hand, with the note of its curator
+type inputLink struct {
+	URL  string `json:"url"`
+	Note string `json:"note,omitempty"`
+}
+
+// Read a list of product links, either a JSON array of {"url", "note"}
+// objects or a bookmarks export of a browser, whose descriptions are the notes
+func loadInputLinks(path string) ([]inputLink, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var links []inputLink
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("[")) {
+		err = json.Unmarshal(data, &links)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	} else {
+		links, err = bookmarkLinks(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+	}
+
+	// Bookmark folders hold anything, only the products can be scraped
+	var products []inputLink
+	for _, link := range links {
+		link.URL = strings.TrimSpace(link.URL)
+		if !strings.Contains(link.URL, "/product/") {
+			log.Println("Skipping", link.URL, "which is not a product page")
+			continue
+		}
+		products = append(products, link)
+	}
+	return products, nil
+}
+
+// Links of a bookmarks file in the Netscape format, shared by every browser
+func bookmarkLinks(data []byte) ([]inputLink, error) {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	var links []inputLink
+	doc.Find(`a[href]`).Each(func(_ int, s *goquery.Selection) {
+		link := inputLink{URL: s.AttrOr("href", "")}
+		// Descriptions follow the entry of their bookmark
+		next := s.ParentsFiltered("dt").First().Next()
+		if goquery.NodeName(next) == "dd" {
+			link.Note = strings.TrimSpace(next.Contents().First().Text())
+		}
+		links = append(links, link)
+	})
+	return links, nil
+}
+
+// Scrape and export each of the links, returning how many were exported
+func scrapeLinks(links []inputLink, opts crawlOptions) (int, error) {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	filenames, err := loadFilenameRegistry(opts.FilenameMap, opts.Format.Ext)
+	if err != nil {
+		return 0, err
+	}
+
+	exported := 0
+	for _, link := range links {
+		result, err := opts.Scraper.scrapeProduct(link.URL, "")
+		if err != nil {
+			log.Println(link.URL, "-", err)
+			opts.notifyFailure(link.URL, err)
+			continue
+		}
+		cardSet := result.CardSet
+		cardSet.Note = link.Note
+		if opts.Layout == layoutDrop {
+			cardSet.Filename = dropOutputName(cardSet)
+		}
+		cardSet.Filename = filenames.Resolve(cardSet.Filename, link.URL, cardSet.ReleaseDate)
+		printCardSet(os.Stderr, cardSet, opts.Color)
+
+		written, err := exportCardSet(cardSet, cardSet.Filename, opts)
+		if err != nil {
+			log.Println(err)
+			opts.notifyFailure(link.URL, err)
+			continue
+		}
+		if written == fileHeld {
+			log.Println(link.URL, "-", errHeld)
+			continue
+		}
+		opts.notifyDrop(cardSet)
+		exported++
+	}
+
+	return exported, filenames.Save()
+}
@@ -0,0 +1,151 @@
+package sldownloader
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+type lintIssue struct {
+	Line    int
+	Message string
+}
+
+// Leading numeric portion of a collector number, or -1 if there is none
+func collectorNumberValue(number string) int {
+	end := 0
+	for end < len(number) && number[end] >= '0' && number[end] <= '9' {
+		end++
+	}
+	value, err := strconv.Atoi(number[:end])
+	if err != nil {
+		return -1
+	}
+	return value
+}
+
+// Check a generated file for structural problems
+func lintFile(path string) ([]lintIssue, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var issues []lintIssue
+	headers := map[string]string{}
+	numbers := map[string]int{}
+	prevValue, prevLine := -1, 0
+
+	scanner := bufio.NewScanner(file)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") {
+			key, value, found := strings.Cut(strings.TrimPrefix(line, "//"), ":")
+			if found {
+				headers[strings.TrimSpace(key)] = strings.TrimSpace(value)
+			}
+			continue
+		}
+
+		card, err := ParseCardLine(line)
+		if err != nil {
+			issues = append(issues, lintIssue{lineNum, "malformed line: " + err.Error()})
+			continue
+		}
+
+		if card.Number == "" {
+			issues = append(issues, lintIssue{lineNum, "missing collector number for " + card.Name})
+			continue
+		}
+
+		key := card.Number + "|" + cardFinish(card)
+		if first, found := numbers[key]; found {
+			issues = append(issues, lintIssue{lineNum, fmt.Sprintf("duplicate collector number %s (first on line %d)", card.Number, first)})
+		} else {
+			numbers[key] = lineNum
+		}
+
+		value := collectorNumberValue(card.Number)
+		if value >= 0 {
+			if value < prevValue {
+				issues = append(issues, lintIssue{lineNum, fmt.Sprintf("collector number %s is lower than the one on line %d", card.Number, prevLine)})
+			}
+			prevValue, prevLine = value, lineNum
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	for _, header := range []string{"NAME", "SOURCE", "DATE"} {
+		if headers[header] == "" {
+			issues = append(issues, lintIssue{0, "missing " + header + " header"})
+		}
+	}
+
+	// Same transformation applied by cleanTitle
+	title := headers["NAME"]
+	if title != "" {
+		expected := strings.TrimSpace(strings.Replace(title, ":", "-", -1))
+		name := strings.TrimSuffix(filepath.Base(path), ".txt")
+		if name != expected {
+			issues = append(issues, lintIssue{0, fmt.Sprintf("filename does not match title (expected '%s.txt')", expected)})
+		}
+	}
+
+	return issues, nil
+}
+
+func runLint(args []string) int {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader lint dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	total := 0
+	for _, path := range files {
+		issues, err := lintFile(path)
+		if err != nil {
+			log.Println(err)
+			total++
+			continue
+		}
+		for _, issue := range issues {
+			if issue.Line > 0 {
+				fmt.Printf("%s:%d: %s\n", path, issue.Line, issue.Message)
+			} else {
+				fmt.Printf("%s: %s\n", path, issue.Message)
+			}
+		}
+		total += len(issues)
+	}
+
+	log.Println("Checked", len(files), "files,", total, "issues found")
+	if total > 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,93 @@
+package sldownloader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Lock file held while an instance writes to the current directory, used
+// when there is no cache directory
+const lockFileName = ".sldownloader.lock"
+
+var errLocked = errors.New("another sldownloader instance is writing to this directory")
+
+// Keep the lock out of the output directory, so it's not mistaken for output
+func outputLockPath() string {
+	cacheDir, err := os.UserCacheDir()
+	if err != nil {
+		return lockFileName
+	}
+	dir, err := filepath.Abs(".")
+	if err != nil {
+		return lockFileName
+	}
+	hash := sha256.Sum256([]byte(dir))
+	return filepath.Join(cacheDir, "sldownloader", "locks", hex.EncodeToString(hash[:8])+".lock")
+}
+
+// Crawls and re-scrapes of this process, which would see the lock file as
+// held by another instance
+var outputMu sync.Mutex
+
+// Take the lock of the output directory, after any crawl or re-scrape of this
+// process is done with it, and return the function releasing it
+func lockOutput(wait time.Duration) (func(), error) {
+	outputMu.Lock()
+	release, err := acquireLock(outputLockPath(), wait)
+	if err != nil {
+		outputMu.Unlock()
+		return nil, err
+	}
+	return func() {
+		release()
+		outputMu.Unlock()
+	}, nil
+}
+
+// Take the lock at path, waiting up to wait for any other instance to release
+// it, and return the function releasing it. The lock is tied to the open file,
+// so it goes away with the process even if it crashes.
+func acquireLock(path string, wait time.Duration) (func(), error) {
+	err := os.MkdirAll(filepath.Dir(path), 0755)
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(wait)
+	logged := false
+	for {
+		err = tryLock(file)
+		if err == nil {
+			break
+		}
+		if !errors.Is(err, errLocked) || time.Now().After(deadline) {
+			file.Close()
+			return nil, err
+		}
+		if !logged {
+			log.Println("Waiting for another instance to finish...")
+			logged = true
+		}
+		time.Sleep(time.Second)
+	}
+
+	// Only informative, to find out who holds the lock
+	file.Truncate(0)
+	fmt.Fprintln(file, os.Getpid())
+
+	return func() {
+		unlock(file)
+		file.Close()
+	}, nil
+}
@@ -0,0 +1,14 @@
+//go:build !unix && !windows
+
+package sldownloader
+
+import "os"
+
+// File locks are not available, so instances are not serialized
+func tryLock(file *os.File) error {
+	return nil
+}
+
+func unlock(file *os.File) error {
+	return nil
+}
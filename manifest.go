@@ -0,0 +1,106 @@
+package sldownloader
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// Description of the files generated by a crawl
+type runManifest struct {
+	GeneratedAt time.Time      `json:"generated_at"`
+	Files       []manifestFile `json:"files"`
+}
+
+type manifestFile struct {
+	Name        string `json:"name"`
+	Title       string `json:"title"`
+	Link        string `json:"link"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Sequence    int    `json:"sequence,omitempty"`
+	Cards       int    `json:"cards"`
+	SHA256      string `json:"sha256"`
+}
+
+// Build the manifest of the files exported for the input card sets
+func buildManifest(cardSets []*CardSet, ext string) (*runManifest, error) {
+	manifest := runManifest{
+		GeneratedAt: time.Now().UTC(),
+	}
+	for _, cardSet := range cardSets {
+		name := cardSet.Filename + ext
+		data, err := os.ReadFile(name)
+		if err != nil {
+			return nil, err
+		}
+		hash := sha256.Sum256(data)
+
+		manifest.Files = append(manifest.Files, manifestFile{
+			Name:        name,
+			Title:       cardSet.Title,
+			Link:        cardSet.Link,
+			ReleaseDate: cardSet.ReleaseDate,
+			Sequence:    cardSet.Sequence,
+			Cards:       len(cardSet.Cards),
+			SHA256:      hex.EncodeToString(hash[:]),
+		})
+	}
+	return &manifest, nil
+}
+
+// Create a tar.gz archive with the files of the manifest and the manifest itself
+func buildBundle(manifest *runManifest) ([]byte, error) {
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(gz)
+
+	addFile := func(name string, data []byte) error {
+		err := tw.WriteHeader(&tar.Header{
+			Name:    name,
+			Mode:    0644,
+			Size:    int64(len(data)),
+			ModTime: manifest.GeneratedAt,
+		})
+		if err != nil {
+			return err
+		}
+		_, err = tw.Write(data)
+		return err
+	}
+
+	for _, file := range manifest.Files {
+		data, err := os.ReadFile(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		err = addFile(file.Name, data)
+		if err != nil {
+			return nil, err
+		}
+	}
+	err = addFile("manifest.json", manifestData)
+	if err != nil {
+		return nil, err
+	}
+
+	err = tw.Close()
+	if err != nil {
+		return nil, err
+	}
+	err = gz.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
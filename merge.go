@@ -0,0 +1,83 @@
+package sldownloader
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Union several card sets, dropping cards with the same name, number, and finish
+func mergeCardSets(title string, cardSets []*CardSet) *CardSet {
+	var merged CardSet
+
+	var titles, links []string
+	seen := map[string]int{}
+	for _, cardSet := range cardSets {
+		if !slices.Contains(titles, cardSet.Title) {
+			titles = append(titles, cardSet.Title)
+		}
+		if cardSet.Link != "" && !slices.Contains(links, cardSet.Link) {
+			links = append(links, cardSet.Link)
+		}
+		if cardSet.ReleaseDate != "" && (merged.ReleaseDate == "" || cardSet.ReleaseDate < merged.ReleaseDate) {
+			merged.ReleaseDate = cardSet.ReleaseDate
+		}
+
+		for _, card := range cardSet.Cards {
+			key := cardKey(card) + "|" + card.Number
+			idx, found := seen[key]
+			if found {
+				merged.Cards[idx].Count = max(merged.Cards[idx].Count, card.Count)
+				continue
+			}
+			seen[key] = len(merged.Cards)
+			merged.Cards = append(merged.Cards, card)
+		}
+	}
+
+	merged.Title = title
+	if merged.Title == "" {
+		merged.Title = strings.Join(titles, " + ")
+	}
+	merged.Link = strings.Join(links, ", ")
+
+	return &merged
+}
+
+func runMerge(args []string) int {
+	fs := flag.NewFlagSet("merge", flag.ExitOnError)
+	outOpt := fs.String("o", "", "Name of the merged file, without extension")
+	titleOpt := fs.String("title", "", "Title of the merged drop, by default the input titles are joined")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader merge file1.txt file2.txt... -o combined")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) < 2 || *outOpt == "" {
+		fs.Usage()
+		return 1
+	}
+
+	var cardSets []*CardSet
+	for _, path := range paths {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		cardSets = append(cardSets, cardSet)
+	}
+
+	merged := mergeCardSets(*titleOpt, cardSets)
+	merged.Filename = *outOpt
+
+	_, err := dumpCards(merged, merged.Filename, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
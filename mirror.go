@@ -0,0 +1,86 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+)
+
+// The raw store catalog, as returned by the store search service
+type catalogMirror struct {
+	FetchedAt time.Time         `json:"fetched_at"`
+	Host      string            `json:"host"`
+	UserID    string            `json:"user_id"`
+	Total     int               `json:"total"`
+	Products  []json.RawMessage `json:"products"`
+}
+
+// Page through the whole store search, keeping every product record as is
+func mirrorCatalog(config scalefastConfig) (*catalogMirror, error) {
+	mirror := &catalogMirror{
+		FetchedAt: time.Now().UTC(),
+		Host:      config.Host,
+		UserID:    config.UserID,
+	}
+
+	for offset := 0; ; offset += maxItemsInResp {
+		link := config.searchURL(offset)
+
+		var page struct {
+			Total    int               `json:"total"`
+			Products []json.RawMessage `json:"products"`
+		}
+		err := fetchCatalog(link, &page)
+		if err != nil {
+			return nil, err
+		}
+
+		mirror.Total = page.Total
+		mirror.Products = append(mirror.Products, page.Products...)
+		log.Println("Mirrored", len(mirror.Products), "of", page.Total, "products")
+
+		if len(page.Products) < maxItemsInResp || len(mirror.Products) >= page.Total {
+			break
+		}
+	}
+
+	return mirror, nil
+}
+
+func runMirror(args []string) int {
+	fs := flag.NewFlagSet("mirror", flag.ExitOnError)
+	outOpt := fs.String("out", "catalog.json", "File where the catalog is written")
+	scalefastHostOpt := fs.String("scalefast-host", defaultScalefast.Host, "Host of the store search service")
+	scalefastUserOpt := fs.String("scalefast-user-id", defaultScalefast.UserID, "Store tenant in the store search service")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader mirror [-out catalog.json]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	mirror, err := mirrorCatalog(scalefastConfig{
+		Host:   *scalefastHostOpt,
+		UserID: *scalefastUserOpt,
+	})
+	if err != nil {
+		log.Println("Unable to mirror the catalog:", err)
+		return 1
+	}
+
+	data, err := json.MarshalIndent(mirror, "", "  ")
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	err = os.WriteFile(*outOpt, append(data, '\n'), 0644)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println("Wrote", len(mirror.Products), "products to", *outOpt)
+	return 0
+}
@@ -0,0 +1,67 @@
+package sldownloader
+
+import (
+	"context"
+	"log"
+	"net/url"
+	"path"
+	"strings"
+)
+
+// Storages able to list the links of the drops they hold
+type linkLister interface {
+	DropLinks(ctx context.Context) ([]string, error)
+}
+
+// Identify a product by the element of its link following "product", or the
+// last one, so that the locale of the store doesn't matter
+func productKey(link string) string {
+	u, err := url.Parse(link)
+	if err != nil || u.Path == "" {
+		return link
+	}
+	elems := strings.Split(strings.Trim(u.Path, "/"), "/")
+	for i, elem := range elems {
+		if elem == "product" && i+1 < len(elems) {
+			return elems[i+1]
+		}
+	}
+	return path.Base(u.Path)
+}
+
+// Collect the products already exported, in the output directory and in the
+// storages that can tell
+func exportedProducts(opts crawlOptions) (map[string]bool, error) {
+	known := map[string]bool{}
+
+	files, err := listOutputFiles([]string{outputDir}, outputFormats["txt"])
+	if err != nil {
+		return nil, err
+	}
+	for _, file := range files {
+		cardSet, err := loadCardSet(file)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if cardSet.Link != "" {
+			known[productKey(cardSet.Link)] = true
+		}
+	}
+
+	for _, store := range opts.Stores {
+		lister, ok := store.(linkLister)
+		if !ok {
+			continue
+		}
+		links, err := lister.DropLinks(context.Background())
+		if err != nil {
+			return nil, err
+		}
+		for _, link := range links {
+			known[productKey(link)] = true
+		}
+	}
+
+	return known, nil
+}
@@ -0,0 +1,64 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"time"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+const mqttTimeout = 10 * time.Second
+
+// Publish a JSON event to a MQTT topic for each drop and failure
+type mqttNotifier struct {
+	client mqtt.Client
+	topic  string
+}
+
+func newMQTTNotifier(broker, topic, username, password string) (*mqttNotifier, error) {
+	opts := mqtt.NewClientOptions()
+	opts.AddBroker(broker)
+	opts.SetClientID("sldownloader")
+	opts.SetUsername(username)
+	opts.SetPassword(password)
+	opts.SetAutoReconnect(true)
+
+	client := mqtt.NewClient(opts)
+	token := client.Connect()
+	if !token.WaitTimeout(mqttTimeout) {
+		return nil, mqtt.ErrNotConnected
+	}
+	if token.Error() != nil {
+		return nil, token.Error()
+	}
+
+	return &mqttNotifier{
+		client: client,
+		topic:  topic,
+	}, nil
+}
+
+func (m *mqttNotifier) NotifyDrop(cardSet *CardSet) error {
+	return m.publish(newDropEvent(cardSet))
+}
+
+func (m *mqttNotifier) NotifyFailure(link string, err error) error {
+	return m.publish(newFailureEvent(link, err))
+}
+
+func (m *mqttNotifier) publish(event dropEvent) error {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	token := m.client.Publish(m.topic, 1, false, payload)
+	if !token.WaitTimeout(mqttTimeout) {
+		return mqtt.ErrNotConnected
+	}
+	return token.Error()
+}
+
+func (m *mqttNotifier) Close() {
+	m.client.Disconnect(250)
+}
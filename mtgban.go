@@ -0,0 +1,130 @@
+package sldownloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"slices"
+	"strings"
+	"sync"
+)
+
+const (
+	mtgjsonSLDURL = "https://mtgjson.com/api/v5/SLD.json"
+
+	// The set file is large but bounded
+	maxMTGJSONSize = 256 << 20
+)
+
+type mtgjsonCard struct {
+	UUID     string   `json:"uuid"`
+	Name     string   `json:"name"`
+	FaceName string   `json:"faceName"`
+	Number   string   `json:"number"`
+	Side     string   `json:"side"`
+	Finishes []string `json:"finishes"`
+}
+
+// Resolve MTGBAN identifiers, which are the MTGJSON UUID of a printing with
+// a _f or _e suffix for the foil or etched finish of cards that come in more
+// than one finish
+type mtgbanResolver struct {
+	once     sync.Once
+	err      error
+	byNumber map[string][]mtgjsonCard
+}
+
+func (r *mtgbanResolver) load() error {
+	r.once.Do(func() {
+		retryClient := newRetryClient()
+
+		resp, err := retryClient.Get(mtgjsonSLDURL)
+		if err != nil {
+			r.err = err
+			return
+		}
+		defer resp.Body.Close()
+
+		var setFile struct {
+			Data struct {
+				Cards  []mtgjsonCard `json:"cards"`
+				Tokens []mtgjsonCard `json:"tokens"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxMTGJSONSize)).Decode(&setFile)
+		if err != nil {
+			r.err = err
+			return
+		}
+
+		r.byNumber = map[string][]mtgjsonCard{}
+		for _, card := range append(setFile.Data.Cards, setFile.Data.Tokens...) {
+			r.byNumber[card.Number] = append(r.byNumber[card.Number], card)
+		}
+		log.Println("Loaded", len(r.byNumber), "MTGJSON printings")
+	})
+	return r.err
+}
+
+func (r *mtgbanResolver) Resolve(card CardData) (string, error) {
+	if card.Number == "" {
+		return "", errors.New("missing collector number")
+	}
+	// Only the main set is indexed
+	if card.Set != "" {
+		return "", fmt.Errorf("printings in %s are not indexed", card.Set)
+	}
+	err := r.load()
+	if err != nil {
+		return "", err
+	}
+
+	// Faced cards carry an extra suffix in our numbering
+	candidates := r.byNumber[card.Number]
+	if len(candidates) == 0 {
+		candidates = r.byNumber[baseNumber(card.Number)]
+	}
+	side := "a"
+	if strings.HasSuffix(card.Number, "b") {
+		side = "b"
+	}
+
+	for _, candidate := range candidates {
+		name := candidate.FaceName
+		if name == "" {
+			name = strings.Split(candidate.Name, " // ")[0]
+		}
+		if name != card.Name || (candidate.Side != "" && candidate.Side != side) {
+			continue
+		}
+
+		id := candidate.UUID
+		if len(candidate.Finishes) > 1 {
+			switch {
+			case card.Etched && slices.Contains(candidate.Finishes, "etched"):
+				id += "_e"
+			case card.Foil && slices.Contains(candidate.Finishes, "foil"):
+				id += "_f"
+			}
+		}
+		return id, nil
+	}
+
+	return "", errors.New("printing not found in MTGJSON")
+}
+
+// Fill in the identifiers of every card of the set
+func (r *mtgbanResolver) Enrich(ctx context.Context, cardSet *CardSet) error {
+	for i, card := range cardSet.Cards {
+		id, err := r.Resolve(card)
+		if err != nil {
+			log.Println(card.Name, "- unable to resolve MTGBAN id:", err)
+			continue
+		}
+		cardSet.Cards[i].MTGBANID = id
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package sldownloader
+
+import (
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// A notifier alerts someone about the outcome of a drop scrape
+type notifier interface {
+	// A drop was scraped and exported successfully
+	NotifyDrop(cardSet *CardSet) error
+	// A drop could not be scraped and needs attention
+	NotifyFailure(link string, err error) error
+}
+
+// Drops whose export was already up to date were notified when they changed
+// last, so only new and updated drops are notified
+func (opts crawlOptions) notifyDrop(cardSet *CardSet) {
+	if cardSet.unchanged {
+		return
+	}
+	for _, n := range opts.Notifiers {
+		err := n.NotifyDrop(cardSet)
+		if err != nil {
+			log.Println("notification failed:", err)
+		}
+	}
+}
+
+// Notifiers describing the run of their events are told when a scheduled run
+// starts, so that the events of each run tell when it did
+type runStarter interface {
+	StartRun(at time.Time)
+}
+
+func (opts crawlOptions) startRun() {
+	at := time.Now().UTC()
+	for _, n := range opts.Notifiers {
+		if starter, ok := n.(runStarter); ok {
+			starter.StartRun(at)
+		}
+	}
+}
+
+func (opts crawlOptions) notifyFailure(link string, err error) {
+	for _, n := range opts.Notifiers {
+		nerr := n.NotifyFailure(link, err)
+		if nerr != nil {
+			log.Println("notification failed:", nerr)
+		}
+	}
+}
+
+// Plain text summary of a drop, for human-readable notifications
+func describeCardSet(cardSet *CardSet) string {
+	var sb strings.Builder
+	fmt.Fprintln(&sb, cardSet.Title)
+	fmt.Fprintln(&sb, cardSet.Link)
+	if cardSet.ReleaseDate != "" {
+		fmt.Fprintln(&sb, "Release date:", cardSet.ReleaseDate)
+	}
+	if cardSet.Status != "" {
+		fmt.Fprintln(&sb, "Status:", cardSet.Status)
+	}
+	for _, price := range cardSet.Prices {
+		fmt.Fprintln(&sb, "Price:", price)
+	}
+	if cardSet.Value != nil {
+		fmt.Fprintln(&sb, "Singles value:", cardSet.Value)
+	}
+	if cardSet.Description != "" {
+		fmt.Fprintln(&sb)
+		fmt.Fprintln(&sb, cardSet.Description)
+	}
+	if len(cardSet.NewCards) > 0 {
+		fmt.Fprintln(&sb)
+		fmt.Fprintln(&sb, "New to Secret Lair:", strings.Join(cardSet.NewCards, ", "))
+	}
+	if len(cardSet.Reprints) > 0 {
+		if len(cardSet.NewCards) == 0 {
+			fmt.Fprintln(&sb)
+		}
+		fmt.Fprintln(&sb, "Reprints:", strings.Join(cardSet.Reprints, ", "))
+	}
+	fmt.Fprintln(&sb)
+	for _, card := range cardSet.Cards {
+		fmt.Fprintln(&sb, formatCard(card))
+	}
+	return sb.String()
+}
+
+// Machine-readable representation of a notification
+type dropEvent struct {
+	Event   string    `json:"event"`
+	Time    time.Time `json:"time"`
+	Link    string    `json:"link"`
+	CardSet *CardSet  `json:"card_set,omitempty"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// Drops that just shipped get their own event, since they were already
+// notified while in preorder
+func newDropEvent(cardSet *CardSet) dropEvent {
+	event := "drop"
+	if cardSet.shipped {
+		event = "shipped"
+	}
+	return dropEvent{
+		Event:   event,
+		Time:    time.Now().UTC(),
+		Link:    cardSet.Link,
+		CardSet: cardSet,
+	}
+}
+
+func newFailureEvent(link string, err error) dropEvent {
+	return dropEvent{
+		Event: "failure",
+		Time:  time.Now().UTC(),
+		Link:  link,
+		Error: err.Error(),
+	}
+}
@@ -0,0 +1,88 @@
+package sldownloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// We only want to find numbers and special terminator characters
+const ocrWhitelist = "0123456789 ™ ©"
+
+// The collector line of the Japanese frame ends with the language code, which
+// tells the number apart from the digits misread out of the Japanese text
+const ocrWhitelistJapanese = ocrWhitelist + " JP•"
+
+// An OCR engine reading the characters of the whitelist out of an image
+type ocrEngine interface {
+	Text(image []byte, whitelist string) (string, error)
+}
+
+// A tesseract-server instance, reached over HTTP
+type remoteOCR struct {
+	URL string
+}
+
+func (engine remoteOCR) Text(image []byte, whitelist string) (string, error) {
+	options, err := json.Marshal(map[string]any{
+		"languages": []string{"eng"},
+		"configParams": map[string]string{
+			"tessedit_char_whitelist": whitelist,
+		},
+	})
+	if err != nil {
+		return "", err
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	err = form.WriteField("options", string(options))
+	if err != nil {
+		return "", err
+	}
+	part, err := form.CreateFormFile("file", "image.png")
+	if err != nil {
+		return "", err
+	}
+	_, err = part.Write(image)
+	if err != nil {
+		return "", err
+	}
+	err = form.Close()
+	if err != nil {
+		return "", err
+	}
+
+	req, err := retryablehttp.NewRequest(http.MethodPost, engine.URL, body.Bytes())
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+
+	retryClient := newRetryClient()
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("ocr server: %s", resp.Status)
+	}
+
+	var result struct {
+		Data struct {
+			Stdout string `json:"stdout"`
+		} `json:"data"`
+	}
+	err = json.NewDecoder(io.LimitReader(resp.Body, maxProductsResponseSize)).Decode(&result)
+	if err != nil {
+		return "", err
+	}
+	return result.Data.Stdout, nil
+}
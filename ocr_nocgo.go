@@ -0,0 +1,12 @@
+//go:build !cgo
+
+package sldownloader
+
+import "errors"
+
+// Without cgo there is no tesseract library, only remote servers can be used
+type tesseractEngine struct{}
+
+func (tesseractEngine) Text(image []byte, whitelist string) (string, error) {
+	return "", errors.New("built without tesseract, use -ocr-server")
+}
@@ -0,0 +1,23 @@
+//go:build cgo
+
+package sldownloader
+
+import "github.com/otiai10/gosseract/v2"
+
+// The tesseract library linked in the binary
+type tesseractEngine struct{}
+
+func (tesseractEngine) Text(image []byte, whitelist string) (string, error) {
+	client := gosseract.NewClient()
+	defer client.Close()
+
+	err := client.SetWhitelist(whitelist)
+	if err != nil {
+		return "", err
+	}
+	err = client.SetImageFromBytes(image)
+	if err != nil {
+		return "", err
+	}
+	return client.Text()
+}
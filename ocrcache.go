@@ -0,0 +1,109 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"sync"
+	"time"
+)
+
+var errOCRSkipped = errors.New("OCR gave nothing usable on an earlier run")
+
+type ocrFailure struct {
+	Reason string `json:"reason"`
+	// Packaging shots don't take a position in the gallery
+	Packaging bool      `json:"packaging,omitempty"`
+	FailedAt  time.Time `json:"failed_at"`
+}
+
+// Gallery images whose OCR gave nothing usable, skipped by later runs unless
+// forced
+type ocrFailureCache struct {
+	mu sync.Mutex
+
+	Path string
+	// Whether the images are read again anyway
+	Force    bool
+	Failures map[string]ocrFailure
+}
+
+func loadOCRFailureCache(path string, force bool) (*ocrFailureCache, error) {
+	cache := &ocrFailureCache{
+		Path:     path,
+		Force:    force,
+		Failures: map[string]ocrFailure{},
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return cache, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(data, &cache.Failures)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return cache, nil
+}
+
+// The error of the earlier failure of the image, nil if it is to be read
+func (cache *ocrFailureCache) Lookup(link string) error {
+	if cache == nil || cache.Force {
+		return nil
+	}
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+
+	failure, found := cache.Failures[link]
+	if !found {
+		return nil
+	}
+	if failure.Packaging {
+		return errPackagingImage
+	}
+	return fmt.Errorf("%w: %s", errOCRSkipped, failure.Reason)
+}
+
+// Record the outcome of reading the image, only the failures that reading it
+// again would not fix are kept
+func (cache *ocrFailureCache) Record(link, num string, err error) error {
+	if cache == nil {
+		return nil
+	}
+
+	var failure *ocrFailure
+	switch {
+	case errors.Is(err, errPackagingImage):
+		failure = &ocrFailure{Reason: err.Error(), Packaging: true}
+	case errors.Is(err, errUnsupportedImage), errors.Is(err, errUndecodableImage):
+		failure = &ocrFailure{Reason: err.Error()}
+	case err == nil && num == "":
+		failure = &ocrFailure{Reason: "no number read"}
+	case err != nil:
+		// Network and OCR server errors may not happen again
+		return nil
+	}
+
+	cache.mu.Lock()
+	defer cache.mu.Unlock()
+	if failure == nil {
+		if _, found := cache.Failures[link]; !found {
+			return nil
+		}
+		delete(cache.Failures, link)
+	} else {
+		failure.FailedAt = time.Now().UTC()
+		cache.Failures[link] = *failure
+	}
+
+	data, err := json.MarshalIndent(cache.Failures, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(cache.Path, data, 0644)
+}
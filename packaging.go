@@ -0,0 +1,65 @@
+package sldownloader
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	_ "image/gif"
+	_ "image/jpeg"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Cards are 63x88mm, their pictures may be cropped or have a margin
+const (
+	cardAspectRatio = 63.0 / 88.0
+	aspectTolerance = 0.1
+)
+
+var errPackagingImage = errors.New("image shows the packaging, not a card")
+
+// Image names and alt texts of box shots and other promotional pictures, only
+// with words that are unlikely to be part of a card name
+var packagingRegex = regexp.MustCompile(`(?i)packag(e|ing)|box[ _-]?shot|product[ _-]?shot|lifestyle|deck[ _-]?box|playmat|sleeves`)
+
+// Whether the width and height are the ones of a card, in either orientation
+// since some cards are printed sideways
+func isCardAspect(width, height int) bool {
+	if width <= 0 || height <= 0 {
+		return true
+	}
+	ratio := float64(min(width, height)) / float64(max(width, height))
+	return math.Abs(ratio-cardAspectRatio) <= aspectTolerance
+}
+
+// Whether a gallery entry shows the packaging or any other promotional
+// picture, from its link, alt text or declared size
+func isPackagingImage(s *goquery.Selection) bool {
+	link, _ := s.Attr("href")
+	img := s.Find("img")
+	if packagingRegex.MatchString(strings.SplitN(link, "?", 2)[0]) || packagingRegex.MatchString(img.AttrOr("alt", "")) {
+		return true
+	}
+
+	width, errWidth := strconv.Atoi(img.AttrOr("width", ""))
+	height, errHeight := strconv.Atoi(img.AttrOr("height", ""))
+	return errWidth == nil && errHeight == nil && !isCardAspect(width, height)
+}
+
+// Whether the downloaded image has the proportions of a card, images that
+// can't be decoded are assumed to be cards
+func isCardImage(data []byte) bool {
+	if isAVIF(data) {
+		width, height, ok := avifSize(data)
+		return !ok || isCardAspect(width, height)
+	}
+	config, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return true
+	}
+	return isCardAspect(config.Width, config.Height)
+}
@@ -0,0 +1,110 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Words introducing the date of a drop in the text of a product page
+var pageDateKeywords = []string{"release", "available", "on sale", "drop date", "ships", "shipping"}
+
+var pageDateRegex = regexp.MustCompile(`(?i)\b(?:` +
+	`\d{4}-\d{2}-\d{2}|` +
+	`\d{1,2}/\d{1,2}/\d{4}|` +
+	`(?:jan|feb|mar|apr|may|jun|jul|aug|sep|oct|nov|dec)[a-z]*\.? \d{1,2}(?:st|nd|rd|th)?,? \d{4})`)
+
+var ordinalSuffixRegex = regexp.MustCompile(`(\d)(?:st|nd|rd|th)\b`)
+
+var pageDateLayouts = []string{
+	"2006-01-02",
+	"1/2/2006",
+	"January 2, 2006",
+	"January 2 2006",
+	"Jan 2, 2006",
+	"Jan 2 2006",
+	"Jan. 2, 2006",
+}
+
+// Parse a date as written on a product page into the DATE format
+func parsePageDate(text string) (string, bool) {
+	text = ordinalSuffixRegex.ReplaceAllString(strings.TrimSpace(text), "$1")
+	for _, layout := range pageDateLayouts {
+		date, err := time.Parse(layout, text)
+		if err == nil {
+			return date.Format(time.DateOnly), true
+		}
+	}
+	// Abbreviations longer than three letters, such as "Sept."
+	month, rest, found := strings.Cut(text, " ")
+	if found && len(month) > 3 {
+		date, err := time.Parse("Jan 2, 2006", month[:3]+" "+rest)
+		if err == nil {
+			return date.Format(time.DateOnly), true
+		}
+	}
+	return "", false
+}
+
+// Find the release date of the drop in its product page, from the structured
+// data if any, or else from the first date of a line about its availability
+func pageReleaseDate(doc *goquery.Document) string {
+	var date string
+	doc.Find(`script[type="application/ld+json"]`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		var data struct {
+			ReleaseDate string `json:"releaseDate"`
+		}
+		if json.Unmarshal([]byte(s.Text()), &data) != nil || data.ReleaseDate == "" {
+			return true
+		}
+		if len(data.ReleaseDate) >= 10 {
+			date, _ = parsePageDate(data.ReleaseDate[:10])
+		}
+		return date == ""
+	})
+	if date != "" {
+		return date
+	}
+
+	for _, selector := range []string{`meta[itemprop="releaseDate"]`, `meta[property="product:release_date"]`} {
+		content, _ := doc.Find(selector).Attr("content")
+		if len(content) >= 10 {
+			date, found := parsePageDate(content[:10])
+			if found {
+				return date
+			}
+		}
+	}
+
+	return pageDateNear(doc, pageDateKeywords)
+}
+
+// Return the first date of an element of the page mentioning one of the
+// keywords, which are lowercase
+func pageDateNear(doc *goquery.Document, keywords []string) string {
+	var date string
+	doc.Find(`p, li, span, div`).EachWithBreak(func(_ int, s *goquery.Selection) bool {
+		// Only the innermost elements, to keep the date next to its keyword
+		if s.Children().Length() > 0 {
+			return true
+		}
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		lower := strings.ToLower(text)
+		for _, keyword := range keywords {
+			if !strings.Contains(lower, keyword) {
+				continue
+			}
+			match := pageDateRegex.FindString(text)
+			if match == "" {
+				continue
+			}
+			date, _ = parsePageDate(match)
+			return date == ""
+		}
+		return true
+	})
+	return date
+}
@@ -0,0 +1,23 @@
+package sldownloader
+
+import "testing"
+
+func TestParsePageDate(t *testing.T) {
+	for _, text := range []string{
+		"2024-01-22",
+		"1/22/2024",
+		"January 22nd, 2024",
+		"Jan. 22, 2024",
+		"JANUARY 22 2024",
+	} {
+		date, found := parsePageDate(text)
+		if !found || date != "2024-01-22" {
+			t.Errorf("%q: got %q", text, date)
+		}
+	}
+
+	_, found := parsePageDate("soon")
+	if found {
+		t.Error("expected no date")
+	}
+}
@@ -0,0 +1,192 @@
+package sldownloader
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// ParseCardSet reads a card set in the format written by dumpCards, the
+// NAME, SOURCE, and DATE header comments included. Any other comment is ignored.
+func ParseCardSet(r io.Reader) (*CardSet, error) {
+	var cardSet CardSet
+
+	scanner := bufio.NewScanner(r)
+	for lineNum := 1; scanner.Scan(); lineNum++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		if strings.HasPrefix(line, "//") {
+			key, value, found := strings.Cut(strings.TrimPrefix(line, "//"), ":")
+			if !found {
+				continue
+			}
+			value = strings.TrimSpace(value)
+			switch strings.TrimSpace(key) {
+			case "NAME":
+				cardSet.Title = value
+			case "SOURCE":
+				cardSet.Link = value
+			case "DATE":
+				cardSet.ReleaseDate = value
+			case "CATEGORY":
+				cardSet.Category = value
+			case "STATUS":
+				cardSet.Status = value
+			case "NOTE":
+				cardSet.Note = value
+			case "SEQUENCE":
+				cardSet.Sequence, _ = strconv.Atoi(value)
+			}
+			continue
+		}
+
+		card, err := ParseCardLine(line)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", lineNum, err)
+		}
+		cardSet.Cards = append(cardSet.Cards, card)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return &cardSet, nil
+}
+
+// ParseCardLine parses a single card line such as "1 [SLD:123] Card Name [foil]",
+// the inverse of formatCard, optionally followed by a "# ocr, fuzzy_name" comment
+func ParseCardLine(line string) (CardData, error) {
+	var card CardData
+
+	// Annotations of how the number was found
+	line, comment, found := strings.Cut(line, " # ")
+	if found {
+		parseCardComment(&card, comment)
+	}
+
+	countStr, rest, found := strings.Cut(line, " ")
+	if !found {
+		return card, errors.New("missing card name")
+	}
+	count, err := strconv.Atoi(countStr)
+	if err != nil {
+		return card, errors.New("invalid card count")
+	}
+	card.Count = count
+
+	rest = strings.TrimSpace(rest)
+	if !strings.HasPrefix(rest, "[") {
+		return card, errors.New("missing set code")
+	}
+	code, rest, found := strings.Cut(rest[1:], "]")
+	if !found {
+		return card, errors.New("unterminated set code")
+	}
+	set, number, _ := strings.Cut(code, ":")
+	if set == "" {
+		return card, errors.New("missing set code")
+	}
+	if set != "SLD" {
+		card.Set = set
+	}
+	card.Number = number
+
+	name := strings.TrimSpace(rest)
+	for strings.HasSuffix(name, "]") {
+		idx := strings.LastIndex(name, " [")
+		if idx < 0 {
+			break
+		}
+		tag := name[idx+2 : len(name)-1]
+		switch tag {
+		case "foil":
+			card.Foil = true
+		case "etched":
+			card.Etched = true
+		case "token":
+			card.Token = true
+		default:
+			if isComponent(tag) && card.Component == "" {
+				card.Component = tag
+				break
+			}
+			if isLanguageTag(tag) && card.Language == "" {
+				card.Language = tag
+				break
+			}
+			return card, fmt.Errorf("unknown tag %s", name[idx+1:])
+		}
+		name = strings.TrimSpace(name[:idx])
+	}
+	if name == "" {
+		return card, errors.New("missing card name")
+	}
+	card.Name = name
+
+	return card, nil
+}
+
+// Load a card set from a file written by dumpCards, in the format of its
+// extension
+func loadCardSet(path string) (*CardSet, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format := formatOf(path)
+	cardSet, err := format.Read(file)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	cardSet.Filename = strings.TrimSuffix(filepath.Base(path), format.Ext)
+	// In the drop layout the directory identifies the drop
+	if cardSet.Filename == "cards" {
+		cardSet.Filename = filepath.Join(filepath.Base(filepath.Dir(path)), "cards")
+	}
+
+	return cardSet, nil
+}
+
+// Expand the input paths, replacing any directory with the files it contains
+// in the format
+func listOutputFiles(paths []string, format outputFormat) ([]string, error) {
+	var files []string
+	for _, path := range paths {
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			files = append(files, path)
+			continue
+		}
+
+		matches, err := filepath.Glob(filepath.Join(path, "*"+format.Ext))
+		if err != nil {
+			return nil, err
+		}
+		// Drops saved with the drop layout
+		layoutMatches, err := filepath.Glob(filepath.Join(path, "*", "cards"+format.Ext))
+		if err != nil {
+			return nil, err
+		}
+		for _, match := range append(matches, layoutMatches...) {
+			// Other JSON files, such as the failure queue, may be around
+			if format.Ext == ".json" && !isJSONCardSet(match) {
+				continue
+			}
+			files = append(files, match)
+		}
+	}
+	return files, nil
+}
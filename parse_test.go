@@ -0,0 +1,138 @@
+package sldownloader
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestParseCardLine(t *testing.T) {
+	tests := []struct {
+		line string
+		want CardData
+	}{
+		{"1 [SLD:123] Sol Ring", CardData{Name: "Sol Ring", Number: "123", Count: 1}},
+		{"4 [SLD] Island", CardData{Name: "Island", Count: 4}},
+		{"1 [SLD:1508a] Hive Mind [foil]", CardData{Name: "Hive Mind", Number: "1508a", Foil: true, Count: 1}},
+		{"1 [SLD:99] Sol Ring [foil] [etched]", CardData{Name: "Sol Ring", Number: "99", Foil: true, Etched: true, Count: 1}},
+		{"1 [SLD:5] Goblin [token]", CardData{Name: "Goblin", Number: "5", Token: true, Count: 1}},
+		{"1 [SLD:7] Look at Me, I'm R&D", CardData{Name: "Look at Me, I'm R&D", Number: "7", Count: 1}},
+		{"1 [TSLD:3] Treasure [token]", CardData{Name: "Treasure", Set: "TSLD", Number: "3", Count: 1, Token: true}},
+		{"1 [SLD:42] Sol Ring [foil] [it]", CardData{Name: "Sol Ring", Number: "42", Foil: true, Count: 1, Language: "it"}},
+		{"1 [SLD:900] Atraxa, Praetors' Voice [foil] [display_commander]", CardData{Name: "Atraxa, Praetors' Voice", Number: "900", Foil: true, Count: 1, Component: "display_commander"}},
+	}
+
+	for _, test := range tests {
+		card, err := ParseCardLine(test.line)
+		if err != nil {
+			t.Errorf("%q: unexpected error: %v", test.line, err)
+			continue
+		}
+		if !reflect.DeepEqual(card, test.want) {
+			t.Errorf("%q: got %+v, want %+v", test.line, card, test.want)
+		}
+		if formatCard(card) != test.line {
+			t.Errorf("%q: formatted back as %q", test.line, formatCard(card))
+		}
+	}
+}
+
+func TestParseCardLineErrors(t *testing.T) {
+	for _, line := range []string{
+		"Sol Ring",
+		"x [SLD:1] Sol Ring",
+		"1 Sol Ring",
+		"1 [SLD:1 Sol Ring",
+		"1 [SLD:1]",
+		"1 [SLD:1] Sol Ring [shiny]",
+	} {
+		_, err := ParseCardLine(line)
+		if err == nil {
+			t.Errorf("%q: expected an error", line)
+		}
+	}
+}
+
+func TestDumpCardsRoundTrip(t *testing.T) {
+	tests := []CardSet{
+		{
+			Title:       "Bob Ross: Happy Little Gathering",
+			Link:        "https://secretlair.wizards.com/us/product/1",
+			ReleaseDate: "2020-08-03",
+			Cards: []CardData{
+				{Name: "Plains", Number: "1", Count: 1},
+				{Name: "Island", Number: "2", Count: 1},
+				{Name: "Evolving Wilds", Number: "", Count: 2, Foil: true},
+			},
+		},
+		{
+			Title: "Phyrexian Praetors: Foil Etched",
+			Link:  "https://secretlair.wizards.com/us/product/2",
+			Cards: []CardData{
+				{Name: "Elesh Norn", Number: "1500a", Count: 1, Foil: true, Etched: true},
+				{Name: "Phyrexian", Number: "42", Count: 1, Token: true},
+			},
+		},
+	}
+
+	dir := t.TempDir()
+	for _, want := range tests {
+		path := filepath.Join(dir, "drop")
+		_, err := dumpCards(&want, path, outputFormats["txt"])
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := loadCardSet(path + ".txt")
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		want.Filename = "drop"
+		if !reflect.DeepEqual(*got, want) {
+			t.Errorf("round trip mismatch:\ngot  %+v\nwant %+v", *got, want)
+		}
+	}
+}
+
+func TestParseCardSetErrors(t *testing.T) {
+	input := "// NAME: Test\n1 [SLD:1] Sol Ring\nnot a card\n"
+	_, err := ParseCardSet(strings.NewReader(input))
+	if err == nil || !strings.Contains(err.Error(), "line 3") {
+		t.Errorf("expected an error on line 3, got %v", err)
+	}
+}
+
+func TestParseCardSetIgnoresComments(t *testing.T) {
+	input := "// NAME: Test\n// Some note\n\n1 [SLD:1] Sol Ring\n"
+	cardSet, err := ParseCardSet(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cardSet.Title != "Test" || len(cardSet.Cards) != 1 {
+		t.Errorf("unexpected card set %+v", cardSet)
+	}
+}
+
+func TestParseCardLineComment(t *testing.T) {
+	card, err := ParseCardLine("1 [SLD:123] Card Name [foil] # ocr, reassigned_image")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if card.Name != "Card Name" || card.Number != "123" || !card.Foil {
+		t.Errorf("unexpected card %+v", card)
+	}
+	if card.NumberSource != "ocr" || !slices.Equal(card.Flags, []string{"reassigned_image"}) {
+		t.Errorf("unexpected annotations %q %q", card.NumberSource, card.Flags)
+	}
+
+	line, err := formatTxtLine(card, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if line != "1 [SLD:123] Card Name [foil] # ocr, reassigned_image" {
+		t.Errorf("unexpected line %q", line)
+	}
+}
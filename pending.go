@@ -0,0 +1,181 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"time"
+)
+
+// A drop that was exported before Scryfall knew about it, so its numbers
+// are missing or only come from OCR
+type pendingDrop struct {
+	Link        string    `json:"link"`
+	Title       string    `json:"title"`
+	Filename    string    `json:"filename"`
+	ReleaseDate string    `json:"release_date,omitempty"`
+	Names       []string  `json:"names"`
+	AddedAt     time.Time `json:"added_at"`
+	Attempts    int       `json:"attempts"`
+}
+
+type pendingQueue struct {
+	Path  string
+	Drops []pendingDrop
+}
+
+func loadPendingQueue(path string) (*pendingQueue, error) {
+	queue := &pendingQueue{Path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &queue.Drops)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// Add the drop to the queue, or refresh it if already present
+func (queue *pendingQueue) Add(cardSet *CardSet) {
+	var names []string
+	for _, card := range cardSet.Cards {
+		names = append(names, card.Name)
+	}
+
+	drop := pendingDrop{
+		Link:        cardSet.Link,
+		Title:       cardSet.Title,
+		Filename:    cardSet.Filename,
+		ReleaseDate: cardSet.ReleaseDate,
+		Names:       names,
+		AddedAt:     time.Now().UTC(),
+	}
+
+	i := slices.IndexFunc(queue.Drops, func(other pendingDrop) bool {
+		return other.Link == cardSet.Link
+	})
+	if i < 0 {
+		queue.Drops = append(queue.Drops, drop)
+		return
+	}
+	drop.AddedAt = queue.Drops[i].AddedAt
+	drop.Attempts = queue.Drops[i].Attempts
+	queue.Drops[i] = drop
+}
+
+func (queue *pendingQueue) Remove(link string) {
+	queue.Drops = slices.DeleteFunc(queue.Drops, func(drop pendingDrop) bool {
+		return drop.Link == link
+	})
+}
+
+func (queue *pendingQueue) Failed(link string) {
+	for i := range queue.Drops {
+		if queue.Drops[i].Link == link {
+			queue.Drops[i].Attempts++
+		}
+	}
+}
+
+func (queue *pendingQueue) Save() error {
+	data, err := json.MarshalIndent(queue.Drops, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(queue.Path, data, 0644)
+}
+
+// Scrape again the drops of the queue, rewriting the files of those that
+// Scryfall now knows about, and return how many were resolved
+func retryPending(path string, opts crawlOptions) (int, error) {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	queue, err := loadPendingQueue(path)
+	if err != nil {
+		return 0, err
+	}
+
+	resolved := 0
+	for _, drop := range slices.Clone(queue.Drops) {
+		result, err := opts.Scraper.scrapeProduct(drop.Link, drop.ReleaseDate)
+		if err != nil {
+			log.Println(drop.Link, "-", err)
+			queue.Failed(drop.Link)
+			continue
+		}
+		cardSet := result.CardSet
+		if cardSet.unmatched {
+			log.Println(drop.Title, "is still unknown to Scryfall")
+			queue.Failed(drop.Link)
+			continue
+		}
+
+		if drop.ReleaseDate != "" {
+			cardSet.ReleaseDate = drop.ReleaseDate
+		}
+		cardSet.Filename = drop.Filename
+		written, err := exportCardSet(cardSet, drop.Filename, opts)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		// Retried until the changes are accepted
+		if written == fileHeld {
+			log.Println(drop.Title, "-", errHeld)
+			continue
+		}
+		queue.Remove(drop.Link)
+		resolved++
+	}
+
+	return resolved, queue.Save()
+}
+
+func runRetryPending(args []string) int {
+	fs := flag.NewFlagSet("retry-pending", flag.ExitOnError)
+	doOCROpt := fs.Bool("ocr", false, "Use OCR for the drops that are still unknown to Scryfall")
+	headersCacheOpt := fs.String("headers-cache", defaultHeadersCachePath(), "File where the Scryfall set headers are persisted between runs, empty to disable")
+	headersTTLOpt := fs.Duration("headers-ttl", defaultHeadersTTL, "How long the persisted Scryfall set headers are considered fresh")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader retry-pending [-ocr] pending.json")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) != 1 {
+		fs.Usage()
+		return 1
+	}
+
+	scraper := newScraper(*headersCacheOpt, *headersTTLOpt)
+	scraper.OCR = *doOCROpt
+	opts := crawlOptions{
+		Scraper: scraper,
+		Format:  outputFormats["txt"],
+		Layout:  layoutFlat,
+	}
+	resolved, err := retryPending(paths[0], opts)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	log.Println(resolved, "pending drops resolved")
+	return 0
+}
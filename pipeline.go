@@ -0,0 +1,293 @@
+package sldownloader
+
+import (
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Stages of a crawl after the discovery of the products, in order. Exporting
+// is done by the crawl itself, one product at a time.
+var pipelineStages = []string{"fetch", "parse", "match", "ocr"}
+
+// A product of the catalog flowing through the crawl pipeline
+type crawlItem struct {
+	product     ScalefastProduct
+	page        int
+	link        string
+	releaseDate string
+	category    string
+
+	scrape *productScrape
+	// The first error of any stage, the later ones let the item through
+	err error
+}
+
+// Activity of a stage of the pipeline, updated by its workers
+type stageMetrics struct {
+	Name    string
+	Workers int
+
+	mu        sync.Mutex
+	processed int
+	failed    int
+	busy      time.Duration
+}
+
+func (metrics *stageMetrics) observe(start time.Time, err error) {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	metrics.processed++
+	if err != nil {
+		metrics.failed++
+	}
+	metrics.busy += time.Since(start)
+}
+
+func (metrics *stageMetrics) String() string {
+	metrics.mu.Lock()
+	defer metrics.mu.Unlock()
+	return fmt.Sprintf("%s: %d workers, %d products, %d failed, %s busy",
+		metrics.Name, metrics.Workers, metrics.processed, metrics.failed, metrics.busy.Round(time.Millisecond))
+}
+
+// Parse a list such as "fetch=2,ocr=4" into the number of workers of each
+// stage, the stages not listed get one
+func parseStageWorkers(spec string) (map[string]int, error) {
+	workers := map[string]int{}
+	for _, stage := range pipelineStages {
+		workers[stage] = 1
+	}
+	if spec == "" {
+		return workers, nil
+	}
+
+	for _, field := range strings.Split(spec, ",") {
+		stage, value, found := strings.Cut(strings.TrimSpace(field), "=")
+		if !found {
+			return nil, fmt.Errorf("invalid stage workers %q", field)
+		}
+		if _, known := workers[stage]; !known {
+			return nil, fmt.Errorf("unknown stage %q", stage)
+		}
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return nil, fmt.Errorf("invalid number of workers for %s", stage)
+		}
+		workers[stage] = n
+	}
+	return workers, nil
+}
+
+// Run fn over the items of in with the given number of workers. Items that
+// already failed are passed along untouched. The order is not preserved.
+func runStage(in <-chan *crawlItem, metrics *stageMetrics, fn func(item *crawlItem) error) <-chan *crawlItem {
+	out := make(chan *crawlItem)
+
+	var wg sync.WaitGroup
+	for range metrics.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range in {
+				if item.err == nil {
+					start := time.Now()
+					item.err = fn(item)
+					metrics.observe(start, item.err)
+				}
+				out <- item
+			}
+		}()
+	}
+
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+
+	return out
+}
+
+// Page through the catalog from the given page, sending every product to
+// scrape except the known ones. The first source is the main one, paged from
+// the given page up to the maximum number of pages if any, and the page a
+// later crawl can start from is sent on next when done. When crawling the
+// newest products, the main source stops at the first known product. The
+// other sources are gone through entirely afterwards, for the products the
+// main source doesn't list.
+func discoverProducts(page int, opts crawlOptions, known map[string]bool, timings *phaseTimings, next chan<- int) <-chan *crawlItem {
+	out := make(chan *crawlItem)
+
+	go func() {
+		defer close(out)
+
+		skipped := 0
+		reachedKnown := false
+		seen := map[string]bool{}
+		send := func(product ScalefastProduct, page int) {
+			if seen[product.ProductID] {
+				return
+			}
+			seen[product.ProductID] = true
+
+			releaseDate := ""
+			if !product.ReleaseDate.IsZero() {
+				releaseDate = product.ReleaseDate.Format("2006-01-02")
+			}
+
+			// Skip any bundle and special releases, unless requested
+			category := ""
+			for _, desc := range product.Descriptions {
+				category = productCategory(desc.Title)
+				if category != "" {
+					fmt.Printf("\"%s\",%s\n", desc.Title, releaseDate)
+					break
+				}
+			}
+			if category != "" && !opts.IncludeBundles {
+				return
+			}
+			if known[product.ProductID] {
+				skipped++
+				if opts.Newest && !reachedKnown {
+					log.Println("Reached product", product.ProductID, "already exported")
+					reachedKnown = true
+				}
+				return
+			}
+
+			out <- &crawlItem{
+				product:     product,
+				page:        page,
+				link:        storefrontURL + "/us/product/" + product.ProductID,
+				releaseDate: releaseDate,
+				category:    category,
+			}
+		}
+		fetch := func(source productSource, page int) ([]ScalefastProduct, error) {
+			start := time.Now()
+			defer timings.observe("store", start)
+			return source.Products(page)
+		}
+
+		primary := opts.catalog()
+		var extra []productSource
+		if len(opts.Sources) > 0 {
+			extra = opts.Sources[1:]
+		}
+
+		// The products of the earlier pages are not new to the other sources
+		if len(extra) > 0 {
+			for i := range page {
+				products, err := fetch(primary, i)
+				if err != nil {
+					log.Println(err)
+					break
+				}
+				for _, product := range products {
+					seen[product.ProductID] = true
+				}
+			}
+		}
+
+		i := page
+		for {
+			products, err := fetch(primary, i)
+			if err != nil {
+				log.Println(err)
+				break
+			}
+			i++
+
+			if len(products) == 0 {
+				break
+			}
+
+			for _, product := range products {
+				send(product, i-1)
+				if reachedKnown {
+					break
+				}
+			}
+			if reachedKnown {
+				break
+			}
+			if opts.MaxPages > 0 && i-page >= opts.MaxPages {
+				log.Println("Stopping after", opts.MaxPages, "pages")
+				break
+			}
+		}
+
+		for _, source := range extra {
+			found := len(seen)
+			sourceSeen := map[string]bool{}
+			for j := range maxArchivePages {
+				products, err := fetch(source, j)
+				if err != nil {
+					log.Println(source.Name(), "-", err)
+					break
+				}
+
+				// Stop when the page has nothing new, the last one may repeat
+				fresh := 0
+				for _, product := range products {
+					if !sourceSeen[product.ProductID] {
+						sourceSeen[product.ProductID] = true
+						fresh++
+					}
+					send(product, j)
+				}
+				if fresh == 0 {
+					break
+				}
+			}
+			log.Println("Found", len(seen)-found, "more products in", source.Name())
+		}
+
+		if skipped > 0 {
+			log.Println("Skipped", skipped, "products already exported")
+		}
+		if opts.Newest {
+			// The next crawl starts again from the newest products
+			next <- page
+			return
+		}
+		next <- i - 2
+	}()
+
+	return out
+}
+
+// Connect the stages that turn the products of the catalog into card sets
+func scrapePipeline(page int, opts crawlOptions, known map[string]bool, timings *phaseTimings, next chan<- int) (<-chan *crawlItem, []*stageMetrics) {
+	var metrics []*stageMetrics
+	for _, stage := range pipelineStages {
+		metrics = append(metrics, &stageMetrics{
+			Name:    stage,
+			Workers: max(opts.StageWorkers[stage], 1),
+		})
+	}
+
+	items := discoverProducts(page, opts, known, timings, next)
+	items = runStage(items, metrics[0], func(item *crawlItem) (err error) {
+		item.scrape, err = opts.Scraper.fetchProduct(item.link)
+		if err == nil {
+			item.scrape.releaseDate = item.releaseDate
+		}
+		return err
+	})
+	items = runStage(items, metrics[1], func(item *crawlItem) error {
+		return item.scrape.parse()
+	})
+	items = runStage(items, metrics[2], func(item *crawlItem) error {
+		return item.scrape.match()
+	})
+	items = runStage(items, metrics[3], func(item *crawlItem) error {
+		return item.scrape.recoverNumbers()
+	})
+
+	return items, metrics
+}
@@ -0,0 +1,111 @@
+package sldownloader
+
+import (
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestParseStageWorkers(t *testing.T) {
+	workers, err := parseStageWorkers("fetch=2, ocr=4")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]int{"fetch": 2, "parse": 1, "match": 1, "ocr": 4}
+	for stage, n := range want {
+		if workers[stage] != n {
+			t.Errorf("%s: got %d workers, want %d", stage, workers[stage], n)
+		}
+	}
+
+	for _, spec := range []string{"fetch", "fetch=0", "fetch=x", "export=2"} {
+		_, err := parseStageWorkers(spec)
+		if err == nil {
+			t.Errorf("%q: expected an error", spec)
+		}
+	}
+}
+
+func TestRunStageSkipsFailedItems(t *testing.T) {
+	in := make(chan *crawlItem)
+	go func() {
+		in <- &crawlItem{link: "ok"}
+		in <- &crawlItem{link: "failed", err: errors.New("earlier stage")}
+		in <- &crawlItem{link: "fails"}
+		close(in)
+	}()
+
+	metrics := &stageMetrics{Name: "test", Workers: 2}
+	out := runStage(in, metrics, func(item *crawlItem) error {
+		if item.link == "failed" {
+			t.Error("failed item was processed")
+		}
+		if item.link == "fails" {
+			return errors.New("this stage")
+		}
+		return nil
+	})
+
+	errs := map[string]string{}
+	for item := range out {
+		errs[item.link] = ""
+		if item.err != nil {
+			errs[item.link] = item.err.Error()
+		}
+	}
+
+	want := map[string]string{"ok": "", "failed": "earlier stage", "fails": "this stage"}
+	for link, err := range want {
+		if errs[link] != err {
+			t.Errorf("%s: got error %q, want %q", link, errs[link], err)
+		}
+	}
+	if metrics.processed != 2 || metrics.failed != 1 {
+		t.Errorf("got %d processed and %d failed, want 2 and 1", metrics.processed, metrics.failed)
+	}
+}
+
+// A catalog of fixed pages of products, by id
+type fakeSource [][]string
+
+func (source fakeSource) Name() string {
+	return "fake"
+}
+
+func (source fakeSource) Products(page int) ([]ScalefastProduct, error) {
+	var products []ScalefastProduct
+	if page < len(source) {
+		for _, id := range source[page] {
+			products = append(products, ScalefastProduct{ProductID: id})
+		}
+	}
+	return products, nil
+}
+
+func TestDiscoverNewestStopsAtKnown(t *testing.T) {
+	source := fakeSource{{"9", "8"}, {"7", "6"}, {"5", "4"}}
+	tests := []struct {
+		name     string
+		maxPages int
+		known    map[string]bool
+		want     []string
+	}{
+		{"stops at known", 0, map[string]bool{"6": true}, []string{"9", "8", "7"}},
+		{"bounded", 1, map[string]bool{"4": true}, []string{"9", "8"}},
+		{"nothing known", 0, nil, []string{"9", "8", "7", "6", "5", "4"}},
+	}
+	for _, test := range tests {
+		opts := crawlOptions{Sources: []productSource{source}, Newest: true, MaxPages: test.maxPages}
+		next := make(chan int, 1)
+		var got []string
+		for item := range discoverProducts(0, opts, test.known, newPhaseTimings(), next) {
+			got = append(got, item.product.ProductID)
+		}
+		if !slices.Equal(got, test.want) {
+			t.Errorf("%s: got %v, want %v", test.name, got, test.want)
+		}
+		if page := <-next; page != 0 {
+			t.Errorf("%s: next crawl starts from page %d, want 0", test.name, page)
+		}
+	}
+}
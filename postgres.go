@@ -0,0 +1,412 @@
+package sldownloader
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Arbitrary key used to serialize migrations across multiple instances
+const postgresMigrationLock = 0x534c44
+
+// Each entry is applied once, in order, and never modified after release
+var postgresMigrations = []string{
+	`CREATE TABLE drops (
+		id           SERIAL PRIMARY KEY,
+		title        TEXT NOT NULL,
+		filename     TEXT NOT NULL,
+		link         TEXT NOT NULL UNIQUE,
+		release_date DATE,
+		updated_at   TIMESTAMPTZ NOT NULL
+	)`,
+	`CREATE TABLE cards (
+		drop_id  INTEGER NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+		position INTEGER NOT NULL,
+		name     TEXT NOT NULL,
+		number   TEXT NOT NULL,
+		foil     BOOLEAN NOT NULL,
+		etched   BOOLEAN NOT NULL,
+		token    BOOLEAN NOT NULL,
+		count    INTEGER NOT NULL,
+		PRIMARY KEY (drop_id, position)
+	)`,
+	`CREATE INDEX cards_name_idx ON cards (name)`,
+	`ALTER TABLE cards ADD COLUMN tcgplayer_id INTEGER`,
+	`ALTER TABLE cards ADD COLUMN cardmarket_id INTEGER`,
+	`ALTER TABLE drops
+		ADD COLUMN first_seen TIMESTAMPTZ,
+		ADD COLUMN last_seen  TIMESTAMPTZ,
+		ADD COLUMN gone_at    TIMESTAMPTZ`,
+	`ALTER TABLE drops ADD COLUMN description TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE drops ADD COLUMN category TEXT NOT NULL DEFAULT ''`,
+	`ALTER TABLE cards ADD COLUMN language TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE printings (
+		set_code   TEXT NOT NULL,
+		number     TEXT NOT NULL,
+		name       TEXT NOT NULL,
+		drop_id    INTEGER REFERENCES drops(id) ON DELETE SET NULL,
+		first_seen TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (set_code, number)
+	);
+	INSERT INTO printings (set_code, number, name, drop_id, first_seen)
+	SELECT DISTINCT ON (c.number) 'SLD', c.number, c.name, c.drop_id, d.updated_at
+	FROM cards c JOIN drops d ON d.id = c.drop_id
+	WHERE c.number <> ''
+	ORDER BY c.number, d.release_date NULLS LAST, d.id`,
+	`ALTER TABLE drops
+		ADD COLUMN status    TEXT NOT NULL DEFAULT '',
+		ADD COLUMN ship_date DATE`,
+	`CREATE TABLE drop_prices (
+		drop_id            INTEGER NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+		label              TEXT NOT NULL,
+		amount             NUMERIC NOT NULL,
+		currency           TEXT NOT NULL,
+		reference_amount   NUMERIC,
+		reference_currency TEXT
+	)`,
+	`ALTER TABLE cards ADD COLUMN treatments TEXT[]`,
+	`ALTER TABLE drops ADD COLUMN tcgplayer_product_id INTEGER`,
+	`CREATE TABLE printing_claims (
+		set_code   TEXT NOT NULL,
+		number     TEXT NOT NULL,
+		drop_id    INTEGER NOT NULL REFERENCES drops(id) ON DELETE CASCADE,
+		name       TEXT NOT NULL,
+		first_seen TIMESTAMPTZ NOT NULL,
+		PRIMARY KEY (set_code, number, drop_id)
+	);
+	INSERT INTO printing_claims (set_code, number, drop_id, name, first_seen)
+	SELECT DISTINCT ON (c.number, c.drop_id) 'SLD', c.number, c.drop_id, c.name, COALESCE(p.first_seen, d.updated_at)
+	FROM cards c JOIN drops d ON d.id = c.drop_id
+		LEFT JOIN printings p ON p.set_code = 'SLD' AND p.number = c.number AND p.drop_id = c.drop_id
+	WHERE c.number <> ''
+	ORDER BY c.number, c.drop_id, c.position`,
+}
+
+type postgresStore struct {
+	db *sql.DB
+}
+
+func newPostgresStore(ctx context.Context, dsn string) (*postgresStore, error) {
+	db, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, err
+	}
+
+	err = migratePostgres(ctx, db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+
+	return &postgresStore{db: db}, nil
+}
+
+func migratePostgres(ctx context.Context, db *sql.DB) error {
+	// Use a single connection so that the advisory lock is held throughout
+	conn, err := db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, "SELECT pg_advisory_lock($1)", postgresMigrationLock)
+	if err != nil {
+		return err
+	}
+	defer conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", postgresMigrationLock)
+
+	_, err = conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER PRIMARY KEY)`)
+	if err != nil {
+		return err
+	}
+
+	var version int
+	err = conn.QueryRowContext(ctx, `SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return err
+	}
+
+	for i := version; i < len(postgresMigrations); i++ {
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		_, err = tx.ExecContext(ctx, postgresMigrations[i])
+		if err == nil {
+			_, err = tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, i+1)
+		}
+		if err != nil {
+			tx.Rollback()
+			return err
+		}
+		err = tx.Commit()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Replace any previous version of the drop (identified by its link) and its cards
+func (store *postgresStore) SaveCardSet(ctx context.Context, cardSet *CardSet) error {
+	tx, err := store.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var releaseDate sql.NullString
+	if cardSet.ReleaseDate != "" {
+		releaseDate.String = cardSet.ReleaseDate
+		releaseDate.Valid = true
+	}
+
+	var shipDate sql.NullString
+	if cardSet.ShipDate != "" {
+		shipDate.String = cardSet.ShipDate
+		shipDate.Valid = true
+	}
+
+	var tcgplayerProductID sql.NullInt64
+	if cardSet.TCGplayerProductID != 0 {
+		tcgplayerProductID.Int64 = int64(cardSet.TCGplayerProductID)
+		tcgplayerProductID.Valid = true
+	}
+
+	var firstSeen, lastSeen, goneAt sql.NullTime
+	if cardSet.Availability != nil {
+		firstSeen = sql.NullTime{Time: cardSet.Availability.FirstSeen, Valid: true}
+		lastSeen = sql.NullTime{Time: cardSet.Availability.LastSeen, Valid: true}
+		if cardSet.Availability.GoneAt != nil {
+			goneAt = sql.NullTime{Time: *cardSet.Availability.GoneAt, Valid: true}
+		}
+	}
+
+	var dropID int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO drops (title, filename, link, release_date, updated_at, first_seen, last_seen, gone_at, description, category, status, ship_date, tcgplayer_product_id)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)
+		ON CONFLICT (link) DO UPDATE SET
+			title = EXCLUDED.title,
+			description = EXCLUDED.description,
+			category = EXCLUDED.category,
+			status = EXCLUDED.status,
+			ship_date = COALESCE(EXCLUDED.ship_date, drops.ship_date),
+			tcgplayer_product_id = COALESCE(EXCLUDED.tcgplayer_product_id, drops.tcgplayer_product_id),
+			filename = EXCLUDED.filename,
+			release_date = COALESCE(EXCLUDED.release_date, drops.release_date),
+			updated_at = EXCLUDED.updated_at,
+			first_seen = COALESCE(drops.first_seen, EXCLUDED.first_seen),
+			last_seen = COALESCE(EXCLUDED.last_seen, drops.last_seen),
+			gone_at = CASE WHEN EXCLUDED.last_seen IS NULL THEN drops.gone_at ELSE EXCLUDED.gone_at END
+		RETURNING id`,
+		cardSet.Title, cardSet.Filename, cardSet.Link, releaseDate, time.Now().UTC(),
+		firstSeen, lastSeen, goneAt, cardSet.Description, cardSet.Category,
+		cardSet.Status, shipDate, tcgplayerProductID,
+	).Scan(&dropID)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM cards WHERE drop_id = $1`, dropID)
+	if err != nil {
+		return err
+	}
+
+	var setCodes, numbers []string
+	for i, card := range cardSet.Cards {
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO cards (drop_id, position, name, number, foil, etched, token, count, tcgplayer_id, cardmarket_id, language, treatments)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, NULLIF($9, 0), NULLIF($10, 0), $11, $12)`,
+			dropID, i, card.Name, card.Number, card.Foil, card.Etched, card.Token, card.Count,
+			card.TCGplayerID, card.CardmarketID, card.Language, pq.Array(card.Treatments),
+		)
+		if err != nil {
+			return err
+		}
+
+		if card.Number == "" {
+			continue
+		}
+		setCodes = append(setCodes, printingSetCode(card))
+		numbers = append(numbers, card.Number)
+
+		// Every drop claiming a number is recorded, keeping when it first did
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO printing_claims (set_code, number, drop_id, name, first_seen)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (set_code, number, drop_id) DO UPDATE SET name = EXCLUDED.name`,
+			printingSetCode(card), card.Number, dropID, card.Name, time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+
+		// The first drop claiming a number owns it in the registry, and
+		// corrects it when saved again
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO printings (set_code, number, name, drop_id, first_seen)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (set_code, number) DO UPDATE SET name = EXCLUDED.name
+			WHERE printings.drop_id = EXCLUDED.drop_id`,
+			printingSetCode(card), card.Number, card.Name, dropID, time.Now().UTC(),
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	// The numbers the drop no longer claims go to the next drop claiming them
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM printing_claims
+		WHERE drop_id = $1 AND (set_code, number) NOT IN (SELECT * FROM UNNEST($2::TEXT[], $3::TEXT[]))`,
+		dropID, pq.Array(setCodes), pq.Array(numbers),
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		DELETE FROM printings p
+		WHERE p.drop_id = $1 AND NOT EXISTS (
+			SELECT 1 FROM printing_claims c
+			WHERE c.set_code = p.set_code AND c.number = p.number AND c.drop_id = p.drop_id)`,
+		dropID,
+	)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO printings (set_code, number, name, drop_id, first_seen)
+		SELECT DISTINCT ON (c.set_code, c.number) c.set_code, c.number, c.name, c.drop_id, c.first_seen
+		FROM printing_claims c
+		WHERE NOT EXISTS (SELECT 1 FROM printings p WHERE p.set_code = c.set_code AND p.number = c.number)
+		ORDER BY c.set_code, c.number, c.first_seen, c.drop_id`,
+	)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.ExecContext(ctx, `DELETE FROM drop_prices WHERE drop_id = $1`, dropID)
+	if err != nil {
+		return err
+	}
+	for _, price := range cardSet.Prices {
+		var referenceAmount sql.NullFloat64
+		var referenceCurrency sql.NullString
+		if price.Reference != nil {
+			referenceAmount = sql.NullFloat64{Float64: price.Reference.Amount, Valid: true}
+			referenceCurrency = sql.NullString{String: price.Reference.Currency, Valid: true}
+		}
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO drop_prices (drop_id, label, amount, currency, reference_amount, reference_currency)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			dropID, price.Label, price.Amount, price.Currency, referenceAmount, referenceCurrency,
+		)
+		if err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func printingSetCode(card CardData) string {
+	if card.Set == "" {
+		return "SLD"
+	}
+	return card.Set
+}
+
+// Look for the numbers of the drop claimed for a different card by other
+// drops, or registered to one by a drop since deleted
+func (store *postgresStore) PrintingConflicts(ctx context.Context, cardSet *CardSet) ([]printingConflict, error) {
+	var conflicts []printingConflict
+	for _, card := range cardSet.Cards {
+		if card.Number == "" {
+			continue
+		}
+
+		rows, err := store.db.QueryContext(ctx, `
+			SELECT c.name, d.title
+			FROM printing_claims c JOIN drops d ON d.id = c.drop_id
+			WHERE c.set_code = $1 AND c.number = $2 AND c.name <> $3 AND d.link <> $4
+			UNION ALL
+			SELECT p.name, ''
+			FROM printings p
+			WHERE p.set_code = $1 AND p.number = $2 AND p.name <> $3 AND p.drop_id IS NULL`,
+			printingSetCode(card), card.Number, card.Name, cardSet.Link,
+		)
+		if err != nil {
+			return nil, err
+		}
+		for rows.Next() {
+			conflict := printingConflict{Card: card}
+			err = rows.Scan(&conflict.Registered, &conflict.DropTitle)
+			if err != nil {
+				rows.Close()
+				return nil, err
+			}
+			conflicts = append(conflicts, conflict)
+		}
+		err = rows.Err()
+		rows.Close()
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
+// Look for cards whose name contains the input, case insensitively
+func (store *postgresStore) FindCards(ctx context.Context, name string) ([]cardMatch, error) {
+	rows, err := store.db.QueryContext(ctx, `
+		SELECT d.title, COALESCE(TO_CHAR(d.release_date, 'YYYY-MM-DD'), ''),
+			c.name, c.number, c.foil, c.etched, c.token, c.count
+		FROM cards c JOIN drops d ON d.id = c.drop_id
+		WHERE c.name ILIKE '%' || $1 || '%'
+		ORDER BY d.release_date, c.position`, name)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var matches []cardMatch
+	for rows.Next() {
+		var match cardMatch
+		err = rows.Scan(&match.Title, &match.ReleaseDate,
+			&match.Card.Name, &match.Card.Number, &match.Card.Foil,
+			&match.Card.Etched, &match.Card.Token, &match.Card.Count)
+		if err != nil {
+			return nil, err
+		}
+		matches = append(matches, match)
+	}
+	return matches, rows.Err()
+}
+
+// List the links of every stored drop
+func (store *postgresStore) DropLinks(ctx context.Context) ([]string, error) {
+	rows, err := store.db.QueryContext(ctx, `SELECT link FROM drops`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var links []string
+	for rows.Next() {
+		var link string
+		err = rows.Scan(&link)
+		if err != nil {
+			return nil, err
+		}
+		links = append(links, link)
+	}
+	return links, rows.Err()
+}
+
+func (store *postgresStore) Close() error {
+	return store.db.Close()
+}
@@ -0,0 +1,231 @@
+package sldownloader
+
+import (
+	"context"
+	"encoding/json"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"math"
+	"net/http"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Daily reference rates of the European Central Bank, based on the euro
+const ecbRatesURL = "https://www.ecb.europa.eu/stats/eurofxref/eurofxref-daily.xml"
+
+type priceAmount struct {
+	Amount   float64 `json:"amount"`
+	Currency string  `json:"currency"`
+}
+
+// A price of the drop as listed by the storefront, in its own currency, and
+// converted to the reference currency when requested
+type dropPrice struct {
+	// Name of the offer, such as the foil version, when there are several
+	Label string `json:"label,omitempty"`
+	priceAmount
+	Reference *priceAmount `json:"reference,omitempty"`
+}
+
+func (price dropPrice) String() string {
+	out := fmt.Sprintf("%.2f %s", price.Amount, price.Currency)
+	if price.Label != "" {
+		out = price.Label + ": " + out
+	}
+	if price.Reference != nil {
+		out += fmt.Sprintf(" (%.2f %s)", price.Reference.Amount, price.Reference.Currency)
+	}
+	return out
+}
+
+// Collect the offers of the structured data of a product page, or else the
+// price of its meta tags
+func pagePrices(doc *goquery.Document) []dropPrice {
+	var prices []dropPrice
+	doc.Find(`script[type="application/ld+json"]`).Each(func(_ int, s *goquery.Selection) {
+		var data any
+		if json.Unmarshal([]byte(s.Text()), &data) != nil {
+			return
+		}
+		for _, price := range structuredPrices(data) {
+			// Aggregate offers may repeat the price of their only offer
+			if !slices.Contains(prices, price) {
+				prices = append(prices, price)
+			}
+		}
+	})
+	if len(prices) > 0 {
+		return prices
+	}
+
+	for _, prefix := range []string{"product:price", "og:price"} {
+		amount, _ := doc.Find(`meta[property="` + prefix + `:amount"]`).Attr("content")
+		currency, _ := doc.Find(`meta[property="` + prefix + `:currency"]`).Attr("content")
+		value, err := strconv.ParseFloat(strings.TrimSpace(amount), 64)
+		if err == nil && currency != "" {
+			return []dropPrice{{priceAmount: priceAmount{Amount: value, Currency: strings.ToUpper(currency)}}}
+		}
+	}
+	return nil
+}
+
+// Walk the decoded JSON-LD looking for offers, which carry a price and its
+// currency
+func structuredPrices(data any) []dropPrice {
+	var prices []dropPrice
+	switch v := data.(type) {
+	case []any:
+		for _, item := range v {
+			prices = append(prices, structuredPrices(item)...)
+		}
+	case map[string]any:
+		currency, _ := v["priceCurrency"].(string)
+		if currency != "" {
+			for _, key := range []string{"price", "lowPrice"} {
+				amount, found := jsonNumber(v[key])
+				if !found {
+					continue
+				}
+				label, _ := v["name"].(string)
+				prices = append(prices, dropPrice{
+					Label:       label,
+					priceAmount: priceAmount{Amount: amount, Currency: strings.ToUpper(currency)},
+				})
+				break
+			}
+		}
+		// In a stable order, so that the exports don't change between runs
+		for _, key := range slices.Sorted(maps.Keys(v)) {
+			prices = append(prices, structuredPrices(v[key])...)
+		}
+	}
+	return prices
+}
+
+// Prices are either numbers or strings in JSON-LD
+func jsonNumber(value any) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case string:
+		n, err := strconv.ParseFloat(strings.TrimSpace(v), 64)
+		return n, err == nil
+	}
+	return 0, false
+}
+
+// A source of exchange rates, as the units of each currency worth one unit
+// of a common base currency
+type ratesSource interface {
+	Rates(ctx context.Context) (map[string]float64, error)
+}
+
+// Rates read from a JSON file such as {"USD": 1, "EUR": 0.92, "GBP": 0.79},
+// for offline runs or agreed upon rates
+type fileRates struct {
+	Path string
+}
+
+func (source fileRates) Rates(ctx context.Context) (map[string]float64, error) {
+	data, err := os.ReadFile(source.Path)
+	if err != nil {
+		return nil, err
+	}
+	var rates map[string]float64
+	err = json.Unmarshal(data, &rates)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", source.Path, err)
+	}
+	return rates, nil
+}
+
+// The daily rates of the ECB, downloaded at most once a day
+type ecbRates struct {
+	mu        sync.Mutex
+	rates     map[string]float64
+	fetchedAt time.Time
+}
+
+func (source *ecbRates) Rates(ctx context.Context) (map[string]float64, error) {
+	source.mu.Lock()
+	defer source.mu.Unlock()
+	if source.rates != nil && time.Since(source.fetchedAt) < 24*time.Hour {
+		return source.rates, nil
+	}
+
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, ecbRatesURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	retryClient := newRetryClient()
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var envelope struct {
+		Cubes []struct {
+			Currency string  `xml:"currency,attr"`
+			Rate     float64 `xml:"rate,attr"`
+		} `xml:"Cube>Cube>Cube"`
+	}
+	err = xml.NewDecoder(io.LimitReader(resp.Body, maxProductsResponseSize)).Decode(&envelope)
+	if err != nil {
+		return nil, err
+	}
+
+	rates := map[string]float64{"EUR": 1}
+	for _, cube := range envelope.Cubes {
+		rates[cube.Currency] = cube.Rate
+	}
+	source.rates = rates
+	source.fetchedAt = time.Now()
+	return rates, nil
+}
+
+// Convert the prices of every drop to a reference currency, keeping the
+// native ones
+type priceNormalizer struct {
+	Currency string
+	Source   ratesSource
+}
+
+func (normalizer priceNormalizer) Enrich(ctx context.Context, cardSet *CardSet) error {
+	if len(cardSet.Prices) == 0 {
+		return nil
+	}
+	rates, err := normalizer.Source.Rates(ctx)
+	if err != nil {
+		return err
+	}
+
+	to, found := rates[normalizer.Currency]
+	if !found {
+		return fmt.Errorf("no exchange rate for %s", normalizer.Currency)
+	}
+	var errs []error
+	for i, price := range cardSet.Prices {
+		from, found := rates[price.Currency]
+		if !found || from == 0 {
+			errs = append(errs, fmt.Errorf("no exchange rate for %s", price.Currency))
+			continue
+		}
+		cardSet.Prices[i].Reference = &priceAmount{
+			Amount:   math.Round(price.Amount/from*to*100) / 100,
+			Currency: normalizer.Currency,
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,84 @@
+package sldownloader
+
+import (
+	"log"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"runtime"
+	"runtime/pprof"
+	"runtime/trace"
+)
+
+// Enable the requested profilers, the returned function stops them and
+// writes any pending profile
+func startProfiling(pprofAddr, cpuProfile, memProfile, traceFile string) (func(), error) {
+	var stops []func()
+	stop := func() {
+		for i := len(stops) - 1; i >= 0; i-- {
+			stops[i]()
+		}
+	}
+
+	if pprofAddr != "" {
+		go func() {
+			log.Println("Serving pprof on", pprofAddr)
+			err := http.ListenAndServe(pprofAddr, nil)
+			if err != nil {
+				log.Println("pprof server:", err)
+			}
+		}()
+	}
+
+	if cpuProfile != "" {
+		f, err := os.Create(cpuProfile)
+		if err != nil {
+			return stop, err
+		}
+		err = pprof.StartCPUProfile(f)
+		if err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			pprof.StopCPUProfile()
+			f.Close()
+		})
+	}
+
+	if traceFile != "" {
+		f, err := os.Create(traceFile)
+		if err != nil {
+			return stop, err
+		}
+		err = trace.Start(f)
+		if err != nil {
+			f.Close()
+			return stop, err
+		}
+		stops = append(stops, func() {
+			trace.Stop()
+			f.Close()
+		})
+	}
+
+	if memProfile != "" {
+		stops = append(stops, func() {
+			f, err := os.Create(memProfile)
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			defer f.Close()
+
+			// Get up-to-date statistics
+			runtime.GC()
+			err = pprof.WriteHeapProfile(f)
+			if err != nil {
+				log.Println(err)
+			}
+		})
+	}
+
+	return stop, nil
+}
@@ -0,0 +1,130 @@
+package sldownloader
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const pushoverURL = "https://api.pushover.net/1/messages.json"
+
+// Forward only the enabled event types to the wrapped notifier
+type filteredNotifier struct {
+	notifier
+	Drops    bool
+	Failures bool
+}
+
+func newFilteredNotifier(n notifier, events string) (*filteredNotifier, error) {
+	var f filteredNotifier
+	f.notifier = n
+	for _, event := range strings.Split(events, ",") {
+		switch strings.TrimSpace(event) {
+		case "drop":
+			f.Drops = true
+		case "failure":
+			f.Failures = true
+		default:
+			return nil, fmt.Errorf("unknown push event %q", event)
+		}
+	}
+	return &f, nil
+}
+
+func (f *filteredNotifier) NotifyDrop(cardSet *CardSet) error {
+	if !f.Drops {
+		return nil
+	}
+	return f.notifier.NotifyDrop(cardSet)
+}
+
+func (f *filteredNotifier) NotifyFailure(link string, err error) error {
+	if !f.Failures {
+		return nil
+	}
+	return f.notifier.NotifyFailure(link, err)
+}
+
+// Publish messages to a ntfy topic, such as https://ntfy.sh/mytopic
+type ntfyNotifier struct {
+	TopicURL string
+	Token    string
+}
+
+func (n *ntfyNotifier) NotifyDrop(cardSet *CardSet) error {
+	return n.publish("New Secret Lair drop: "+cardSet.Title, describeCardSet(cardSet), cardSet.Link, "default")
+}
+
+func (n *ntfyNotifier) NotifyFailure(link string, err error) error {
+	return n.publish("Secret Lair drop needs attention", err.Error(), link, "high")
+}
+
+func (n *ntfyNotifier) publish(title, message, link, priority string) error {
+	req, err := retryablehttp.NewRequest(http.MethodPost, n.TopicURL, strings.NewReader(message))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Title", title)
+	req.Header.Set("Click", link)
+	req.Header.Set("Priority", priority)
+	if n.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+n.Token)
+	}
+
+	return doNotificationRequest(req)
+}
+
+type pushoverNotifier struct {
+	Token string
+	User  string
+}
+
+func (p *pushoverNotifier) NotifyDrop(cardSet *CardSet) error {
+	return p.publish("New Secret Lair drop: "+cardSet.Title, describeCardSet(cardSet), cardSet.Link, "0")
+}
+
+func (p *pushoverNotifier) NotifyFailure(link string, err error) error {
+	return p.publish("Secret Lair drop needs attention", err.Error(), link, "1")
+}
+
+func (p *pushoverNotifier) publish(title, message, link, priority string) error {
+	// Pushover rejects messages longer than this many characters
+	if utf8.RuneCountInString(message) > 1024 {
+		message = string([]rune(message)[:1021]) + "..."
+	}
+
+	form := url.Values{}
+	form.Set("token", p.Token)
+	form.Set("user", p.User)
+	form.Set("title", title)
+	form.Set("message", message)
+	form.Set("url", link)
+	form.Set("priority", priority)
+
+	req, err := retryablehttp.NewRequest(http.MethodPost, pushoverURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	return doNotificationRequest(req)
+}
+
+func doNotificationRequest(req *retryablehttp.Request) error {
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("%s returned %s", req.URL.Host, resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package sldownloader
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BlueMonday/go-scryfall"
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-cleanhttp"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Transport of every HTTP client, replaced to record the interactions of a
+// run or to replay them, the default one of each client is used when nil
+var httpTransport http.RoundTripper
+
+func newRetryClient() *retryablehttp.Client {
+	retryClient := retryablehttp.NewClient()
+	retryClient.Logger = nil
+	if httpTransport != nil {
+		retryClient.HTTPClient.Transport = httpTransport
+	}
+	// A missing recording won't appear by asking again
+	if _, replaying := httpTransport.(*replayTransport); replaying {
+		retryClient.RetryMax = 0
+	}
+	return retryClient
+}
+
+func newHTTPClient() *http.Client {
+	client := cleanhttp.DefaultClient()
+	if httpTransport != nil {
+		client.Transport = httpTransport
+	}
+	return client
+}
+
+func scryfallOptions() []scryfall.ClientOption {
+	if httpTransport == nil {
+		return nil
+	}
+	return []scryfall.ClientOption{
+		scryfall.WithHTTPClient(&http.Client{
+			Transport: httpTransport,
+			Timeout:   30 * time.Second,
+		}),
+	}
+}
+
+// A recorded HTTP interaction, saved as a JSON file of a cassette directory
+type cassetteEntry struct {
+	Method     string      `json:"method"`
+	URL        string      `json:"url"`
+	Status     int         `json:"status"`
+	Header     http.Header `json:"header"`
+	Body       []byte      `json:"body"`
+	RecordedAt time.Time   `json:"recorded_at"`
+}
+
+// Name of the file of a request, from its host and a hash of the method, the
+// URL and the body, so that the same request always maps to the same file
+func cassetteName(method, link string, body []byte) string {
+	hash := sha256.New()
+	io.WriteString(hash, method+"\n"+link+"\n")
+	hash.Write(body)
+
+	host := "request"
+	u, err := url.Parse(link)
+	if err == nil && u.Host != "" {
+		host = strings.ReplaceAll(u.Host, ":", "_")
+	}
+	return host + "-" + hex.EncodeToString(hash.Sum(nil))[:16] + ".json"
+}
+
+// Read the body of a request without consuming it, on a copy of the request
+func readRequestBody(req *http.Request) (*http.Request, []byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return req, nil, nil
+	}
+	body, err := io.ReadAll(req.Body)
+	req.Body.Close()
+	if err != nil {
+		return nil, nil, err
+	}
+	req = req.Clone(req.Context())
+	req.Body = io.NopCloser(bytes.NewReader(body))
+	return req, body, nil
+}
+
+// Save every response received into a cassette directory, a later request
+// with the same method, URL and body replaces the previous one
+type recordingTransport struct {
+	Dir  string
+	Next http.RoundTripper
+}
+
+func newRecordingTransport(dir string) (*recordingTransport, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingTransport{
+		Dir:  dir,
+		Next: cleanhttp.DefaultPooledTransport(),
+	}, nil
+}
+
+func (transport *recordingTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := transport.Next.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	data, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(data))
+
+	entry, err := json.MarshalIndent(cassetteEntry{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		Status:     resp.StatusCode,
+		Header:     resp.Header,
+		Body:       data,
+		RecordedAt: time.Now().UTC(),
+	}, "", "  ")
+	if err == nil {
+		name := filepath.Join(transport.Dir, cassetteName(req.Method, req.URL.String(), body))
+		err = os.WriteFile(name, entry, 0644)
+	}
+	if err != nil {
+		// The run itself is not affected
+		log.Println("Unable to record", req.URL, "-", err)
+	}
+	return resp, nil
+}
+
+var errNotRecorded = errors.New("not recorded")
+
+// Answer every request with the response saved by a recording, so that a run
+// can be reproduced offline
+type replayTransport struct {
+	Dir string
+}
+
+func (transport *replayTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req, body, err := readRequestBody(req)
+	if err != nil {
+		return nil, err
+	}
+
+	name := filepath.Join(transport.Dir, cassetteName(req.Method, req.URL.String(), body))
+	data, err := os.ReadFile(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL, errNotRecorded)
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entry cassetteEntry
+	err = json.Unmarshal(data, &entry)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", entry.Status, http.StatusText(entry.Status)),
+		StatusCode:    entry.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        entry.Header,
+		Body:          io.NopCloser(bytes.NewReader(entry.Body)),
+		ContentLength: int64(len(entry.Body)),
+		Request:       req,
+	}, nil
+}
+
+// Route the HTTP clients created from now on through the transport, or
+// their default one when nil
+func setHTTPTransport(transport http.RoundTripper) {
+	httpTransport = transport
+}
+
+// Link of a saved product page, from its canonical URL
+func savedPageLink(page []byte) string {
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return ""
+	}
+	link, _ := doc.Find(`link[rel="canonical"]`).Attr("href")
+	if link == "" {
+		link, _ = doc.Find(`meta[property="og:url"]`).Attr("content")
+	}
+	return strings.TrimSpace(link)
+}
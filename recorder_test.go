@@ -0,0 +1,63 @@
+package sldownloader
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestRecordReplay(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		w.Header().Set("X-Path", r.URL.Path)
+		io.WriteString(w, r.Method+" "+r.URL.Path+" "+string(body))
+	}))
+	defer server.Close()
+	t.Cleanup(func() { httpTransport = nil })
+
+	dir := t.TempDir()
+	recorder, err := newRecordingTransport(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	httpTransport = recorder
+
+	request := func(path, body string) (string, error) {
+		resp, err := newRetryClient().Post(server.URL+path, "text/plain", strings.NewReader(body))
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		data, err := io.ReadAll(resp.Body)
+		return resp.Header.Get("X-Path") + " " + string(data), err
+	}
+
+	recorded := map[string]string{}
+	for _, path := range []string{"/a", "/b"} {
+		recorded[path], err = request(path, "body"+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	server.Close()
+
+	httpTransport = &replayTransport{Dir: dir}
+	for path, expected := range recorded {
+		replayed, err := request(path, "body"+path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if replayed != expected {
+			t.Errorf("%s: replayed %q, recorded %q", path, replayed, expected)
+		}
+	}
+
+	// Same URL, different body
+	_, err = request("/a", "other")
+	if !errors.Is(err, errNotRecorded) {
+		t.Errorf("expected errNotRecorded, got %v", err)
+	}
+}
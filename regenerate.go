@@ -0,0 +1,142 @@
+package sldownloader
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Find a Scryfall number for the card among the ones not used by other cards
+// of the drop, preferring the one closest to the numbers already in the drop
+func resolveNumber(ctx context.Context, card CardData, used []string) (string, error) {
+	results, err := search(ctx, fmt.Sprintf("e:sld name:\"%s\"", card.Name))
+	if err != nil {
+		return "", err
+	}
+
+	var candidates []string
+	for _, result := range results {
+		if result.Name == card.Name && !slices.Contains(used, result.Number) {
+			candidates = append(candidates, result.Number)
+		}
+	}
+	if len(candidates) == 0 {
+		return "", nil
+	}
+
+	var values []int
+	for _, number := range used {
+		value := collectorNumberValue(number)
+		if value >= 0 {
+			values = append(values, value)
+		}
+	}
+	if len(values) == 0 {
+		return candidates[0], nil
+	}
+	slices.Sort(values)
+	median := values[len(values)/2]
+
+	best := candidates[0]
+	for _, number := range candidates[1:] {
+		if abs(collectorNumberValue(number)-median) < abs(collectorNumberValue(best)-median) {
+			best = number
+		}
+	}
+	return best, nil
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
+
+// Re-resolve missing and suspicious numbers of a file, returning how many
+// cards changed
+func regenerateCardSet(ctx context.Context, cardSet *CardSet) int {
+	changed := 0
+	for i, card := range cardSet.Cards {
+		if card.Number != "" && len(verifyCard(ctx, card)) == 0 {
+			continue
+		}
+
+		var used []string
+		for j, other := range cardSet.Cards {
+			if j != i && other.Number != "" {
+				used = append(used, other.Number)
+			}
+		}
+
+		number, err := resolveNumber(ctx, card, used)
+		if err != nil {
+			log.Println(card.Name, "-", err)
+			continue
+		}
+		if number == "" || number == card.Number {
+			continue
+		}
+
+		log.Printf("%s: %s -> %s", cardSet.Filename, formatCard(card), number)
+		cardSet.Cards[i].Number = number
+		changed++
+	}
+	return changed
+}
+
+func runRegenerate(args []string) int {
+	fs := flag.NewFlagSet("regenerate", flag.ExitOnError)
+	dryRunOpt := fs.Bool("dry-run", false, "Only report the numbers that would change")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader regenerate [-dry-run] dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	if !*dryRunOpt {
+		release, err := lockOutput(0)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		defer release()
+	}
+
+	rewritten := 0
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+
+		if regenerateCardSet(context.Background(), cardSet) == 0 || *dryRunOpt {
+			continue
+		}
+
+		_, err = dumpCards(cardSet, strings.TrimSuffix(path, ".txt"), outputFormats["txt"])
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		rewritten++
+	}
+
+	log.Println("Checked", len(files), "files,", rewritten, "rewritten")
+	return 0
+}
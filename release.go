@@ -0,0 +1,175 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const (
+	githubAPIURL    = "https://api.github.com/repos/"
+	githubUploadURL = "https://uploads.github.com/repos/"
+
+	bundleAssetName   = "sld.tar.gz"
+	manifestAssetName = "manifest.json"
+)
+
+var errReleaseNotFound = errors.New("release not found")
+
+// Publish the run artifacts as assets of a GitHub release, creating the
+// release for the tag if needed and replacing any asset with the same name
+type githubReleaser struct {
+	Repo  string
+	Tag   string
+	Token string
+	// Extension of the exported files
+	Ext string
+
+	// Signs the manifest when set, the signature is uploaded along it
+	Signer *manifestSigner
+}
+
+type githubRelease struct {
+	ID     int `json:"id"`
+	Assets []struct {
+		ID   int    `json:"id"`
+		Name string `json:"name"`
+	} `json:"assets"`
+}
+
+func (gh *githubReleaser) Publish(ctx context.Context, cardSets []*CardSet) error {
+	manifest, err := buildManifest(cardSets, gh.Ext)
+	if err != nil {
+		return err
+	}
+	manifestData, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	bundle, err := buildBundle(manifest)
+	if err != nil {
+		return err
+	}
+
+	release, err := gh.getRelease(ctx)
+	if errors.Is(err, errReleaseNotFound) {
+		release, err = gh.createRelease(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	type releaseAsset struct {
+		name        string
+		contentType string
+		data        []byte
+	}
+	assets := []releaseAsset{
+		{bundleAssetName, "application/gzip", bundle},
+		{manifestAssetName, "application/json", manifestData},
+	}
+	if gh.Signer != nil {
+		ext, signature := gh.Signer.Sign(manifestAssetName, manifestData)
+		assets = append(assets, releaseAsset{manifestAssetName + ext, "application/octet-stream", signature})
+	}
+
+	for _, asset := range assets {
+		for _, old := range release.Assets {
+			if old.Name != asset.name {
+				continue
+			}
+			link := fmt.Sprintf("%s%s/releases/assets/%d", githubAPIURL, gh.Repo, old.ID)
+			_, err = gh.do(ctx, http.MethodDelete, link, "", nil)
+			if err != nil {
+				return err
+			}
+		}
+
+		link := fmt.Sprintf("%s%s/releases/%d/assets?name=%s", githubUploadURL, gh.Repo, release.ID, url.QueryEscape(asset.name))
+		_, err = gh.do(ctx, http.MethodPost, link, asset.contentType, asset.data)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (gh *githubReleaser) getRelease(ctx context.Context) (*githubRelease, error) {
+	link := githubAPIURL + gh.Repo + "/releases/tags/" + url.PathEscape(gh.Tag)
+	data, err := gh.do(ctx, http.MethodGet, link, "", nil)
+	if err != nil {
+		return nil, err
+	}
+
+	var release githubRelease
+	err = json.Unmarshal(data, &release)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (gh *githubReleaser) createRelease(ctx context.Context) (*githubRelease, error) {
+	body, err := json.Marshal(map[string]string{
+		"tag_name": gh.Tag,
+		"name":     gh.Tag,
+		"body":     "Secret Lair drops generated by sldownloader",
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := gh.do(ctx, http.MethodPost, githubAPIURL+gh.Repo+"/releases", "application/json", body)
+	if err != nil {
+		return nil, err
+	}
+
+	var release githubRelease
+	err = json.Unmarshal(data, &release)
+	if err != nil {
+		return nil, err
+	}
+	return &release, nil
+}
+
+func (gh *githubReleaser) do(ctx context.Context, method, link, contentType string, body []byte) ([]byte, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, method, link, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Authorization", "Bearer "+gh.Token)
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode == http.StatusNotFound && method == http.MethodGet {
+		return nil, errReleaseNotFound
+	}
+	if resp.StatusCode/100 != 2 {
+		return nil, fmt.Errorf("github %s %s: %s", method, req.URL.Path, resp.Status)
+	}
+
+	return data, nil
+}
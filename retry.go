@@ -0,0 +1,50 @@
+package sldownloader
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// How many times a failed stage of a scrape is attempted again by default
+const defaultStageRetries = 2
+
+// Errors that another attempt would not fix, such as a Scryfall search
+// without results
+func isPermanent(err error) bool {
+	var scryfallErr *scryfall.Error
+	if errors.As(err, &scryfallErr) {
+		return scryfallErr.Status/100 == 4 && scryfallErr.Status != http.StatusTooManyRequests
+	}
+	return errors.Is(err, errResponseTooLarge) || errors.Is(err, errUnsupportedImage) ||
+		errors.Is(err, errPackagingImage) || errors.Is(err, errNotRecorded) ||
+		errors.Is(err, errOCRSkipped)
+}
+
+// Run one stage of a scrape, retrying transient failures with a linear backoff.
+// Permanent errors are returned as is, while the ones that persisted after
+// every retry are wrapped with the name of the stage.
+func (s *Scraper) retry(stage string, fn func() error) error {
+	retries := max(s.Retries, 0)
+	var err error
+	for attempt := 0; attempt <= retries; attempt++ {
+		if attempt > 0 {
+			log.Printf("Retrying %s (%d/%d) after: %v", stage, attempt, retries, err)
+			time.Sleep(time.Duration(attempt) * time.Second)
+		}
+		err = fn()
+		if err == nil || isPermanent(err) {
+			return err
+		}
+	}
+	return fmt.Errorf("%s: %w", stage, err)
+}
+
+// Whether a stage failed for good after its retries
+func isStageFailure(err error) bool {
+	return err != nil && !isPermanent(err)
+}
@@ -0,0 +1,131 @@
+package sldownloader
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// How filenames are derived from drop titles
+type filenamePolicy struct {
+	// One of "default" (only colons are replaced), "windows" (every
+	// character or name rejected by Windows is avoided) or "posix" (only
+	// path separators are avoided)
+	Mode string
+
+	// Longest filename allowed, in bytes and without extension, 0 to disable
+	MaxBytes int
+
+	// Replace any non-ASCII character with its closest ASCII equivalent
+	ASCII bool
+}
+
+// Leaves room for the extension within the 255 bytes most filesystems allow
+const defaultFilenameMaxBytes = 200
+
+var defaultFilenamePolicy = filenamePolicy{
+	Mode:     "default",
+	MaxBytes: defaultFilenameMaxBytes,
+}
+
+func (policy filenamePolicy) validate() error {
+	switch policy.Mode {
+	case "default", "windows", "posix":
+	default:
+		return fmt.Errorf("unknown filename policy %q", policy.Mode)
+	}
+	if policy.MaxBytes < 0 {
+		return fmt.Errorf("invalid filename length %d", policy.MaxBytes)
+	}
+	return nil
+}
+
+var windowsReplacer = strings.NewReplacer(
+	":", "-",
+	"?", "",
+	"\"", "'",
+	"|", "-",
+)
+
+// Device names that cannot be used as filenames on Windows, regardless of extension
+var windowsReservedNames = []string{
+	"CON", "PRN", "AUX", "NUL",
+	"COM1", "COM2", "COM3", "COM4", "COM5", "COM6", "COM7", "COM8", "COM9",
+	"LPT1", "LPT2", "LPT3", "LPT4", "LPT5", "LPT6", "LPT7", "LPT8", "LPT9",
+}
+
+var asciiReplacer = strings.NewReplacer(
+	"’", "'",
+	"‘", "'",
+	"“", "\"",
+	"”", "\"",
+	"–", "-",
+	"—", "-",
+	"…", "...",
+	"Æ", "AE",
+	"æ", "ae",
+	"ß", "ss",
+)
+
+// Decompose accented letters and drop whatever cannot be represented in ASCII
+func transliterate(s string) string {
+	s = asciiReplacer.Replace(norm.NFD.String(s))
+	return strings.Map(func(r rune) rune {
+		if r > unicode.MaxASCII || unicode.Is(unicode.Mn, r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// Cut the string to at most n bytes, without splitting any character
+func truncateBytes(s string, n int) string {
+	if n <= 0 || len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// Derive a filename from a title that was already stripped of the
+// characters that no filesystem allows
+func sanitizeFilename(title string, policy filenamePolicy) string {
+	if policy.ASCII {
+		title = transliterate(title)
+	}
+
+	switch policy.Mode {
+	case "windows":
+		title = windowsReplacer.Replace(title)
+		title = strings.Map(func(r rune) rune {
+			if r < ' ' {
+				return -1
+			}
+			return r
+		}, title)
+	case "posix":
+		title = strings.ReplaceAll(title, "/", "-")
+	default:
+		title = strings.Replace(title, ":", "-", -1)
+	}
+
+	filename := strings.TrimSpace(truncateBytes(title, policy.MaxBytes))
+
+	if policy.Mode == "windows" {
+		// Trailing dots are silently dropped by Windows
+		filename = strings.TrimRight(filename, ". ")
+		for _, reserved := range windowsReservedNames {
+			if strings.EqualFold(strings.Split(filename, ".")[0], reserved) {
+				filename = "_" + filename
+				break
+			}
+		}
+	}
+
+	return filename
+}
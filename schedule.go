@@ -0,0 +1,79 @@
+package sldownloader
+
+import (
+	"log"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Crawl repeatedly according to the cron expression, each time starting from
+// the page suggested by the previous crawl, until the process is interrupted
+func runScheduled(spec string, jitter time.Duration, page int, opts crawlOptions) error {
+	logger := cron.PrintfLogger(log.Default())
+	c := cron.New(cron.WithChain(
+		cron.Recover(logger),
+		// A crawl may take longer than the interval between runs
+		cron.SkipIfStillRunning(logger),
+	))
+
+	_, err := c.AddFunc(spec, func() {
+		if jitter > 0 {
+			delay := rand.N(jitter)
+			log.Println("Starting scheduled crawl in", delay.Round(time.Second))
+			time.Sleep(delay)
+		}
+		opts.startRun()
+
+		// Drops announced in previous runs may have reached Scryfall meanwhile
+		if opts.Pending != "" {
+			resolved, err := retryPending(opts.Pending, opts)
+			if err != nil {
+				log.Println(err)
+			} else if resolved > 0 {
+				log.Println(resolved, "pending drops resolved")
+			}
+		}
+
+		// Drops that shipped meanwhile are matched again
+		if opts.Preorders != "" {
+			shipped, err := checkPreorders(opts.Preorders, opts)
+			if err != nil {
+				log.Println(err)
+			} else if shipped > 0 {
+				log.Println(shipped, "drops have shipped")
+			}
+		}
+
+		log.Println("Starting scheduled crawl from page", page)
+		next, err := crawl(page, opts)
+		if err != nil {
+			log.Println(err)
+			return
+		}
+		// The newest products are always at the start of the catalog
+		if !opts.Newest {
+			page = max(next, 1)
+		}
+		log.Println("Scheduled crawl done, next one will start from page", page)
+	})
+	if err != nil {
+		return err
+	}
+
+	c.Start()
+	log.Println("Waiting for the next scheduled crawl at", c.Entries()[0].Next)
+
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	<-sig
+
+	log.Println("Shutting down, waiting for any running crawl to complete")
+	<-c.Stop().Done()
+
+	return nil
+}
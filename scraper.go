@@ -0,0 +1,124 @@
+package sldownloader
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+// Scraper scrapes the drops of the Secret Lair store and numbers their cards
+// from Scryfall, for use outside of the command line tool. Its settings must
+// not change while it is scraping.
+type Scraper struct {
+	// Read the numbers that Scryfall doesn't know from the gallery images
+	OCR bool
+	// Don't match the drops on Scryfall, their numbers come from OCR, which
+	// is then enabled, and backfilling only, unvalidated
+	SkipScryfall bool
+	// OCR the gallery of the drops numbered from Scryfall too, reporting the
+	// images that contradict their numbers in the Mismatches of the result
+	VerifyImages bool
+	// How many times a failed stage of a scrape (Scryfall search, image
+	// download and OCR) is retried before giving up on the product
+	Retries int
+	// Scryfall header titles of the drops whose store title doesn't match,
+	// by lowercase store title, taking precedence over the built-in ones
+	Aliases map[string]string
+
+	headers *headerCache
+	// Engine reading the gallery images
+	ocr ocrEngine
+	// Images skipped by OCR, nil when not tracked
+	ocrFailures *ocrFailureCache
+	// Client of the product pages, keeping the cookies across requests
+	storefront *retryablehttp.Client
+	// Product pages read from files rather than the storefront, by link
+	savedPages map[string]string
+	// How the filenames of the drops are derived from their titles
+	filenames filenamePolicy
+	// Where the requests are recorded, nil to discard them
+	audit *auditLog
+}
+
+// NewScraper returns a Scraper persisting the Scryfall headers of the Secret
+// Lair set at cachePath, or only in memory when it is empty. OCR uses the
+// local tesseract library.
+func NewScraper(cachePath string) *Scraper {
+	return newScraper(cachePath, defaultHeadersTTL)
+}
+
+func newScraper(cachePath string, headersTTL time.Duration) *Scraper {
+	return &Scraper{
+		Retries: defaultStageRetries,
+		headers: &headerCache{
+			Path: cachePath,
+			TTL:  headersTTL,
+		},
+		ocr:        tesseractEngine{},
+		storefront: newStorefrontClient(),
+		savedPages: map[string]string{},
+		filenames:  defaultFilenamePolicy,
+	}
+}
+
+// ScrapeProduct downloads the product page at link and returns its drop,
+// with the warnings telling how much its numbers can be trusted
+func (s *Scraper) ScrapeProduct(link string) (*ScrapeResult, error) {
+	return s.scrapeProduct(link, "")
+}
+
+// MatchScryfall numbers the cards of the drop from the Scryfall set matching
+// its title, or else from its release date, and returns the warnings. The
+// gallery images aren't read, the unmatched cards are left unnumbered.
+func (s *Scraper) MatchScryfall(cardSet *CardSet) ([]ScrapeWarning, error) {
+	ps := &productScrape{
+		scraper:  s,
+		cardSet:  *cardSet,
+		cards:    slices.Clone(cardSet.Cards),
+		language: productLanguage(cardSet.Title),
+	}
+	ps.cardSet.timings = newPhaseTimings()
+	for _, card := range ps.cards {
+		ps.listed = append(ps.listed, card.Name)
+	}
+
+	err := ps.match()
+	if err != nil {
+		return nil, err
+	}
+	cardSet.Cards = ps.cards
+	cardSet.unmatched = ps.cardSet.unmatched
+	return ps.warnings, nil
+}
+
+// The Scryfall headers, none when Scryfall is skipped
+func (s *Scraper) loadHeaders(ctx context.Context) ([]scryfallHeader, error) {
+	if s.SkipScryfall {
+		return nil, nil
+	}
+	return s.headers.Load(ctx)
+}
+
+// The Scryfall header title aliased to the store title, empty if there is none
+func (s *Scraper) alias(title string) string {
+	title = strings.ToLower(title)
+	alias, found := s.Aliases[title]
+	if !found {
+		alias = titleAliases[title]
+	}
+	return alias
+}
+
+// The context of the requests of the scrapes, recorded to the audit log
+func (s *Scraper) context() context.Context {
+	return withAuditLog(context.Background(), s.audit)
+}
+
+// ListProducts returns the products of the store on the given page, counted
+// from zero, newest first, and none past the last page
+func ListProducts(page int) ([]ScalefastProduct, error) {
+	return storeSearchSource{Config: defaultScalefast}.Products(page)
+}
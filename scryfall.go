@@ -1,23 +1,138 @@
-package main
+package sldownloader
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"log"
 	"net/http"
 	"net/url"
+	"os"
+	"path/filepath"
 	"slices"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/BlueMonday/go-scryfall"
 	"github.com/PuerkitoBio/goquery"
-	"github.com/hashicorp/go-cleanhttp"
 )
 
 const scryfallURL = "https://scryfall.com/sets/sld"
 const titleClass = ".card-grid-header-content"
 
+// Don't download the headers again more often than this when a drop fails to match
+const minHeadersRefresh = 10 * time.Minute
+
+// How long the persisted headers are used before downloading them again
+const defaultHeadersTTL = 24 * time.Hour
+
 type scryfallHeader struct {
-	Title string
-	URI   string
+	Title string `json:"title"`
+	URI   string `json:"uri"`
+}
+
+// The Scryfall set headers, persisted on disk between runs when Path is set
+type headerCache struct {
+	Path string
+	TTL  time.Duration
+
+	// Products are matched concurrently during a crawl
+	mu        sync.Mutex
+	headers   []scryfallHeader
+	updatedAt time.Time
+}
+
+type headerCacheFile struct {
+	UpdatedAt time.Time        `json:"updated_at"`
+	Headers   []scryfallHeader `json:"headers"`
+}
+
+func defaultHeadersCachePath() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "sldownloader", "scryfall-headers.json")
+}
+
+// Return the headers, from memory or disk when still fresh, from Scryfall
+// otherwise
+func (hc *headerCache) Load(ctx context.Context) ([]scryfallHeader, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if hc.headers != nil && time.Since(hc.updatedAt) < hc.TTL {
+		return hc.headers, nil
+	}
+
+	if hc.Path != "" {
+		data, err := os.ReadFile(hc.Path)
+		if err == nil {
+			var file headerCacheFile
+			err = json.Unmarshal(data, &file)
+			if err == nil && len(file.Headers) > 0 && time.Since(file.UpdatedAt) < hc.TTL {
+				hc.headers = file.Headers
+				hc.updatedAt = file.UpdatedAt
+				log.Println("Loaded Scryfall set page from cache,", len(hc.headers), "products found")
+				return hc.headers, nil
+			}
+		}
+	}
+
+	return hc.fetch(ctx)
+}
+
+// Download the headers again, unless that happened very recently, and report
+// whether they were refreshed
+func (hc *headerCache) Refresh(ctx context.Context) ([]scryfallHeader, bool, error) {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if time.Since(hc.updatedAt) < minHeadersRefresh {
+		return hc.headers, false, nil
+	}
+	headers, err := hc.fetch(ctx)
+	if err != nil {
+		return nil, false, err
+	}
+	return headers, true, nil
+}
+
+func (hc *headerCache) fetch(ctx context.Context) ([]scryfallHeader, error) {
+	headers, err := loadScryfallHeaders(ctx)
+	if err != nil {
+		return nil, err
+	}
+	log.Println("Parsed Scryfall set page,", len(headers), "products found")
+
+	hc.headers = headers
+	hc.updatedAt = time.Now()
+
+	if hc.Path != "" {
+		err = hc.save()
+		if err != nil {
+			log.Println("Unable to persist Scryfall headers:", err)
+		}
+	}
+
+	return headers, nil
+}
+
+func (hc *headerCache) save() error {
+	data, err := json.Marshal(headerCacheFile{
+		UpdatedAt: hc.updatedAt,
+		Headers:   hc.headers,
+	})
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(hc.Path), 0755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(hc.Path, data, 0644)
 }
 
 func loadScryfallHeaders(ctx context.Context) ([]scryfallHeader, error) {
@@ -25,7 +140,7 @@ func loadScryfallHeaders(ctx context.Context) ([]scryfallHeader, error) {
 	if err != nil {
 		return nil, err
 	}
-	resp, err := cleanhttp.DefaultClient().Do(req)
+	resp, err := newHTTPClient().Do(req)
 	if err != nil {
 		return nil, err
 	}
@@ -52,17 +167,59 @@ func loadScryfallHeaders(ctx context.Context) ([]scryfallHeader, error) {
 	return headers, nil
 }
 
-// Make a search call rebuilding the query used in the headers
-func searchURI(ctx context.Context, uri string) ([]CardData, error) {
+// Make a search call rebuilding the query used in the headers, restricted
+// to the printings in the language of the tag if any
+func searchURI(ctx context.Context, uri, lang string) ([]CardData, error) {
 	u, err := url.Parse(uri)
 	if err != nil {
 		return nil, err
 	}
-	return search(ctx, u.Query().Get("q"))
+	return search(ctx, languageQuery(u.Query().Get("q"), lang))
 }
 
-func search(ctx context.Context, query string) ([]CardData, error) {
-	client, err := scryfall.NewClient()
+// Results of the searches performed during the run, by query
+var searchCache = struct {
+	sync.Mutex
+	results map[string][]scryfall.Card
+}{
+	results: map[string][]scryfall.Card{},
+}
+
+// Forget any memoized search, so that long-running processes see new data
+func resetSearchCache() {
+	searchCache.Lock()
+	searchCache.results = map[string][]scryfall.Card{}
+	searchCache.Unlock()
+}
+
+// Run a Scryfall search returning every printing, extras included.
+// Successful results are memoized for the lifetime of the process.
+func searchCards(ctx context.Context, query string) ([]scryfall.Card, error) {
+	searchCache.Lock()
+	cards, found := searchCache.results[query]
+	searchCache.Unlock()
+	if found {
+		return cards, nil
+	}
+
+	cards, err := searchCardsUncached(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+
+	searchCache.Lock()
+	searchCache.results[query] = cards
+	searchCache.Unlock()
+
+	return cards, nil
+}
+
+func searchCardsUncached(ctx context.Context, query string) (cards []scryfall.Card, err error) {
+	defer func() {
+		auditLogOf(ctx).Record("scryfall_search", query, fmt.Sprintf("%d cards", len(cards)), err)
+	}()
+
+	client, err := scryfall.NewClient(scryfallOptions()...)
 	if err != nil {
 		return nil, err
 	}
@@ -77,9 +234,36 @@ func search(ctx context.Context, query string) ([]CardData, error) {
 	if err != nil {
 		return nil, err
 	}
+	return result.Cards, nil
+}
+
+// Whether the card is printed on two physical faces, which are numbered with
+// an a/b suffix, unlike split, flip or adventure cards
+func isDoubleFaced(card scryfall.Card) bool {
+	switch card.Layout {
+	case scryfall.LayoutTransform, scryfall.LayoutModalDFC, scryfall.LayoutMeld,
+		scryfall.LayoutReversible, scryfall.LayoutDoubleFacedToken:
+		return true
+	}
+	return false
+}
+
+// Collector number without the face suffix, as known to Scryfall
+func baseNumber(number string) string {
+	if strings.HasSuffix(number, "a") || strings.HasSuffix(number, "b") {
+		return number[:len(number)-1]
+	}
+	return number
+}
+
+func search(ctx context.Context, query string) ([]CardData, error) {
+	cards, err := searchCards(ctx, query)
+	if err != nil {
+		return nil, err
+	}
 
 	var out []CardData
-	for _, card := range result.Cards {
+	for _, card := range cards {
 		// Make sure to exclude bonus cards, they are tracked elsewhere
 		if slices.Contains(card.PromoTypes, "sldbonus") {
 			continue
@@ -93,19 +277,37 @@ func search(ctx context.Context, query string) ([]CardData, error) {
 		name := strings.Split(card.Name, " // ")[0]
 
 		number := card.CollectorNumber
-		// Special case since upstream treates faces differently
-		if len(card.CardFaces) > 0 {
+		// Special case since upstream numbers each physical face
+		backFace := ""
+		if isDoubleFaced(card) {
 			number += "a"
+			if len(card.CardFaces) > 1 {
+				backFace = card.CardFaces[1].Name
+			}
 		}
 
 		// In case we need it for later
 		isToken := strings.Contains(card.TypeLine, "Token")
 
-		out = append(out, CardData{
+		result := CardData{
 			Name:   name,
 			Number: number,
 			Token:  isToken,
-		})
+
+			Component:  scryfallComponent(card),
+			Treatments: scryfallTreatments(card),
+
+			oracleID: card.OracleID,
+			backFace: backFace,
+			prices:   scryfallPrices(card),
+		}
+		if card.TCGPlayerID != nil {
+			result.TCGplayerID = *card.TCGPlayerID
+		}
+		if card.TCGPlayerEtchedID != nil {
+			result.tcgplayerEtchedID = *card.TCGPlayerEtchedID
+		}
+		out = append(out, result)
 	}
 
 	return out, nil
@@ -0,0 +1,118 @@
+package sldownloader
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// A card of a sealed product in the MTGJSON contents files
+type sealedCard struct {
+	Name   string `json:"name"`
+	Set    string `json:"set"`
+	Number string `json:"number"`
+	Foil   bool   `json:"foil,omitempty"`
+	Etched bool   `json:"etched,omitempty"`
+	Count  int    `json:"count,omitempty"`
+}
+
+type sealedProduct struct {
+	Card []sealedCard `json:"card"`
+}
+
+// Contents of the products of a set, as kept in mtg-sealed-content
+type sealedContents struct {
+	Code     string                   `json:"code"`
+	Products map[string]sealedProduct `json:"products"`
+}
+
+// Name of the drop in the sealed products of MTGJSON
+func sealedProductName(cardSet *CardSet) string {
+	if strings.HasPrefix(cardSet.Title, "Secret Lair") {
+		return cardSet.Title
+	}
+	return "Secret Lair Drop " + cardSet.Title
+}
+
+// Build the contents of the drops, leaving out the cards without a number
+// since they can't be referenced
+func buildSealedContents(cardSets []*CardSet) sealedContents {
+	contents := sealedContents{
+		Code:     "SLD",
+		Products: map[string]sealedProduct{},
+	}
+	for _, cardSet := range cardSets {
+		var product sealedProduct
+		for _, card := range cardSet.Cards {
+			if card.Number == "" {
+				log.Printf("%s: %s has no number, leaving it out of the sealed contents", cardSet.Title, card.Name)
+				continue
+			}
+			entry := sealedCard{
+				Name:   card.Name,
+				Set:    scryfallSetCode(card),
+				Number: card.Number,
+				Foil:   card.Foil,
+				Etched: card.Etched,
+			}
+			if card.Count > 1 {
+				entry.Count = card.Count
+			}
+			product.Card = append(product.Card, entry)
+		}
+		if len(product.Card) > 0 {
+			contents.Products[sealedProductName(cardSet)] = product
+		}
+	}
+	return contents
+}
+
+// Write the YAML of the contents, in the layout of mtg-sealed-content
+func (contents sealedContents) yaml() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "code: %s\nproducts:\n", contents.Code)
+	for _, name := range slices.Sorted(maps.Keys(contents.Products)) {
+		fmt.Fprintf(&b, "  %s:\n    card:\n", strconv.Quote(name))
+		for _, card := range contents.Products[name].Card {
+			fmt.Fprintf(&b, "    - name: %s\n", strconv.Quote(card.Name))
+			fmt.Fprintf(&b, "      set: %s\n", card.Set)
+			fmt.Fprintf(&b, "      number: %s\n", strconv.Quote(card.Number))
+			if card.Foil {
+				fmt.Fprintf(&b, "      foil: true\n")
+			}
+			if card.Etched {
+				fmt.Fprintf(&b, "      etched: true\n")
+			}
+			if card.Count > 0 {
+				fmt.Fprintf(&b, "      count: %d\n", card.Count)
+			}
+		}
+	}
+	return b.Bytes()
+}
+
+// Write the sealed contents of the drops to the file, as JSON or YAML
+// depending on its extension
+func writeSealedContents(path string, cardSets []*CardSet) error {
+	contents := buildSealedContents(cardSets)
+
+	var data []byte
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		data = contents.yaml()
+	default:
+		var err error
+		data, err = json.MarshalIndent(contents, "", "  ")
+		if err != nil {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0644)
+}
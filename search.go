@@ -0,0 +1,112 @@
+package sldownloader
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// A card found in a drop
+type cardMatch struct {
+	Title       string
+	ReleaseDate string
+	Card        CardData
+}
+
+// Storage backends that can be queried for cards
+type cardFinder interface {
+	FindCards(ctx context.Context, name string) ([]cardMatch, error)
+}
+
+// Look for cards whose name contains the query in previously generated files
+func findCardsInFiles(files []string, name string) ([]cardMatch, error) {
+	name = strings.ToLower(name)
+
+	var matches []cardMatch
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			return nil, err
+		}
+		for _, card := range cardSet.Cards {
+			if !strings.Contains(strings.ToLower(card.Name), name) {
+				continue
+			}
+			matches = append(matches, cardMatch{
+				Title:       cardSet.Title,
+				ReleaseDate: cardSet.ReleaseDate,
+				Card:        card,
+			})
+		}
+	}
+	return matches, nil
+}
+
+func runSearch(args []string) int {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	dirOpt := fs.String("dir", ".", "Directory containing the generated files")
+	dbOpt := fs.String("db", "", "Search the database at this URL instead of the files")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader search [-dir dir/ | -db url] \"Card Name\"")
+		fs.PrintDefaults()
+	}
+	query := strings.Join(parseInterspersed(fs, args), " ")
+
+	if query == "" {
+		fs.Usage()
+		return 1
+	}
+
+	var matches []cardMatch
+	if *dbOpt != "" {
+		store, err := openStorage(context.Background(), *dbOpt)
+		if err != nil {
+			log.Println("Unable to open storage:", err)
+			return 1
+		}
+		defer store.Close()
+
+		finder, ok := store.(cardFinder)
+		if !ok {
+			log.Println("This storage backend does not support searching")
+			return 1
+		}
+		matches, err = finder.FindCards(context.Background(), query)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+	} else {
+		files, err := listOutputFiles([]string{*dirOpt}, outputFormats["txt"])
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		matches, err = findCardsInFiles(files, query)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+	}
+
+	slices.SortStableFunc(matches, func(a, b cardMatch) int {
+		return strings.Compare(a.ReleaseDate, b.ReleaseDate)
+	})
+
+	for _, match := range matches {
+		date := match.ReleaseDate
+		if date == "" {
+			date = "unknown date"
+		}
+		fmt.Printf("%s (%s): %s\n", match.Title, date, formatCard(match.Card))
+	}
+
+	if len(matches) == 0 {
+		log.Println("No drop contains", query)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,196 @@
+package sldownloader
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+)
+
+// Numeric range of the collector numbers of a drop, zero when it has none
+func numberRange(cardSet *CardSet) (first, last int) {
+	for _, card := range cardSet.Cards {
+		n, found := collectorNumber(card.Number)
+		if !found || card.Token || (card.Set != "" && card.Set != "SLD") {
+			continue
+		}
+		if first == 0 || n < first {
+			first = n
+		}
+		last = max(last, n)
+	}
+	return first, last
+}
+
+// Order of the drops in the Secret Lair series: by release date, then by
+// their lowest collector number for the drops released on the same day
+func compareDrops(a, b *CardSet) int {
+	if c := cmp.Compare(a.ReleaseDate, b.ReleaseDate); c != 0 {
+		return c
+	}
+	firstA, _ := numberRange(a)
+	firstB, _ := numberRange(b)
+	// Unnumbered drops go last
+	if (firstA == 0) != (firstB == 0) {
+		if firstA == 0 {
+			return 1
+		}
+		return -1
+	}
+	if c := cmp.Compare(firstA, firstB); c != 0 {
+		return c
+	}
+	return cmp.Compare(a.Title, b.Title)
+}
+
+// Whether the drop takes a place in the series: bundles and other special
+// products don't, nor the drops without a release date
+func inSeries(cardSet *CardSet) bool {
+	return cardSet.Category == "" && cardSet.ReleaseDate != ""
+}
+
+// Number the drops by their position in the series, starting from 1
+func assignSequence(cardSets []*CardSet) {
+	var series []*CardSet
+	for _, cardSet := range cardSets {
+		cardSet.Sequence = 0
+		if inSeries(cardSet) {
+			series = append(series, cardSet)
+		}
+	}
+	slices.SortStableFunc(series, compareDrops)
+	for i, cardSet := range series {
+		cardSet.Sequence = i + 1
+	}
+}
+
+// The drops exported so far, to place the new ones in the series
+type seriesTracker struct {
+	drops []*CardSet
+}
+
+func loadSeries(paths []string) (*seriesTracker, error) {
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		return nil, err
+	}
+	series := &seriesTracker{}
+	for _, file := range files {
+		cardSet, err := loadCardSet(file)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		if inSeries(cardSet) {
+			series.drops = append(series.drops, cardSet)
+		}
+	}
+	return series, nil
+}
+
+// Set the position of the drop in the series, among the drops known so far,
+// and remember it for the next ones
+func (series *seriesTracker) Place(cardSet *CardSet) {
+	series.drops = slices.DeleteFunc(series.drops, func(other *CardSet) bool {
+		return productKey(other.Link) == productKey(cardSet.Link)
+	})
+	if !inSeries(cardSet) {
+		cardSet.Sequence = 0
+		return
+	}
+	cardSet.Sequence = 1
+	for _, other := range series.drops {
+		if compareDrops(other, cardSet) < 0 {
+			cardSet.Sequence++
+		}
+	}
+	series.drops = append(series.drops, cardSet)
+}
+
+type indexEntry struct {
+	Sequence    int    `json:"sequence,omitempty"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Link        string `json:"link"`
+	File        string `json:"file"`
+	Cards       int    `json:"cards"`
+	FirstNumber int    `json:"first_number,omitempty"`
+	LastNumber  int    `json:"last_number,omitempty"`
+}
+
+// Print the index of every drop of the generated files, in series order
+func runIndex(args []string) int {
+	fs := flag.NewFlagSet("index", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader index dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(fs.Args(), outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	var cardSets []*CardSet
+	paths := map[*CardSet]string{}
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		cardSets = append(cardSets, cardSet)
+		paths[cardSet] = path
+	}
+	assignSequence(cardSets)
+
+	// Drops out of the series are listed after it
+	slices.SortStableFunc(cardSets, func(a, b *CardSet) int {
+		if (a.Sequence == 0) != (b.Sequence == 0) {
+			if a.Sequence == 0 {
+				return 1
+			}
+			return -1
+		}
+		if c := cmp.Compare(a.Sequence, b.Sequence); c != 0 {
+			return c
+		}
+		return compareDrops(a, b)
+	})
+
+	index := []indexEntry{}
+	for _, cardSet := range cardSets {
+		first, last := numberRange(cardSet)
+		index = append(index, indexEntry{
+			Sequence:    cardSet.Sequence,
+			Title:       cardSet.Title,
+			ReleaseDate: cardSet.ReleaseDate,
+			Category:    cardSet.Category,
+			Link:        cardSet.Link,
+			File:        paths[cardSet],
+			Cards:       len(cardSet.Cards),
+			FirstNumber: first,
+			LastNumber:  last,
+		})
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	err = enc.Encode(index)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	return 0
+}
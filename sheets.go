@@ -0,0 +1,212 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/oauth2/google"
+)
+
+const (
+	sheetsScope          = "https://www.googleapis.com/auth/spreadsheets"
+	sheetsURL            = "https://sheets.googleapis.com/v4/spreadsheets/%s"
+	sheetsAppendURL      = sheetsURL + "/values/%s:append?valueInputOption=RAW&insertDataOption=INSERT_ROWS"
+	sheetsValuesURL      = sheetsURL + "/values/%s"
+	sheetsPropertiesURL  = sheetsURL + "?fields=sheets.properties(sheetId,title)"
+	sheetsBatchUpdateURL = sheetsURL + ":batchUpdate"
+
+	// Column of the drop link in the rows of the cards
+	sheetsLinkColumn = 2
+)
+
+// Append the cards of each drop as rows of a Google Sheet, replacing the rows
+// the drop had
+type sheetsStore struct {
+	client        *http.Client
+	spreadsheetID string
+	sheetRange    string
+}
+
+// Authenticate with the service account key at credentialsPath
+func newSheetsStore(ctx context.Context, credentialsPath, spreadsheetID, sheetRange string) (*sheetsStore, error) {
+	data, err := os.ReadFile(credentialsPath)
+	if err != nil {
+		return nil, err
+	}
+	config, err := google.JWTConfigFromJSON(data, sheetsScope)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sheetsStore{
+		client:        config.Client(ctx),
+		spreadsheetID: spreadsheetID,
+		sheetRange:    sheetRange,
+	}, nil
+}
+
+func (store *sheetsStore) SaveCardSet(ctx context.Context, cardSet *CardSet) error {
+	// The rows of an unchanged drop are there already
+	if cardSet.unchanged {
+		return nil
+	}
+
+	err := store.deleteRows(ctx, cardSet.Link)
+	if err != nil {
+		return err
+	}
+
+	var rows [][]any
+	for _, card := range cardSet.Cards {
+		rows = append(rows, []any{
+			cardSet.Title, cardSet.ReleaseDate, cardSet.Link,
+			card.Count, card.Number, card.Name, card.Foil, card.Etched, card.Token,
+		})
+	}
+
+	link := fmt.Sprintf(sheetsAppendURL, url.PathEscape(store.spreadsheetID), url.PathEscape(store.sheetRange))
+	return store.call(ctx, http.MethodPost, link, map[string]any{"values": rows}, nil)
+}
+
+// Delete the rows of the drop at link, if it was saved before
+func (store *sheetsStore) deleteRows(ctx context.Context, link string) error {
+	var values struct {
+		Range  string  `json:"range"`
+		Values [][]any `json:"values"`
+	}
+	err := store.call(ctx, http.MethodGet, fmt.Sprintf(sheetsValuesURL, url.PathEscape(store.spreadsheetID), url.PathEscape(store.sheetRange)), nil, &values)
+	if err != nil {
+		return err
+	}
+
+	sheet, firstRow := splitA1Range(values.Range)
+	var matches []int
+	for i, row := range values.Values {
+		if len(row) > sheetsLinkColumn && row[sheetsLinkColumn] == link {
+			matches = append(matches, firstRow+i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil
+	}
+
+	sheetID, err := store.sheetID(ctx, sheet)
+	if err != nil {
+		return err
+	}
+
+	// Runs of rows are deleted from the bottom up, so that the indexes of the
+	// ones above stay valid
+	var requests []any
+	for end := len(matches); end > 0; {
+		start := end - 1
+		for start > 0 && matches[start-1] == matches[start]-1 {
+			start--
+		}
+		requests = append(requests, map[string]any{
+			"deleteDimension": map[string]any{
+				"range": map[string]any{
+					"sheetId":    sheetID,
+					"dimension":  "ROWS",
+					"startIndex": matches[start],
+					"endIndex":   matches[end-1] + 1,
+				},
+			},
+		})
+		end = start
+	}
+
+	return store.call(ctx, http.MethodPost, fmt.Sprintf(sheetsBatchUpdateURL, url.PathEscape(store.spreadsheetID)), map[string]any{"requests": requests}, nil)
+}
+
+// Numeric identifier of the sheet with the given title, or of the first one
+// when the title is empty
+func (store *sheetsStore) sheetID(ctx context.Context, title string) (int, error) {
+	var spreadsheet struct {
+		Sheets []struct {
+			Properties struct {
+				SheetID int    `json:"sheetId"`
+				Title   string `json:"title"`
+			} `json:"properties"`
+		} `json:"sheets"`
+	}
+	err := store.call(ctx, http.MethodGet, fmt.Sprintf(sheetsPropertiesURL, url.PathEscape(store.spreadsheetID)), nil, &spreadsheet)
+	if err != nil {
+		return 0, err
+	}
+
+	for _, sheet := range spreadsheet.Sheets {
+		if title == "" || sheet.Properties.Title == title {
+			return sheet.Properties.SheetID, nil
+		}
+	}
+	return 0, fmt.Errorf("sheet %q not found", title)
+}
+
+// Split a range in A1 notation, as returned by the API, into the title of its
+// sheet and the index of its first row, counted from zero
+func splitA1Range(a1 string) (string, int) {
+	sheet, cells := "", a1
+	i := strings.LastIndex(a1, "!")
+	if i >= 0 {
+		sheet, cells = a1[:i], a1[i+1:]
+	}
+	if strings.HasPrefix(sheet, "'") && strings.HasSuffix(sheet, "'") && len(sheet) > 1 {
+		sheet = strings.ReplaceAll(sheet[1:len(sheet)-1], "''", "'")
+	}
+
+	first, _, _ := strings.Cut(cells, ":")
+	row, err := strconv.Atoi(strings.TrimLeft(first, "ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"))
+	if err != nil || row < 1 {
+		return sheet, 0
+	}
+	return sheet, row - 1
+}
+
+// Send the request with body encoded as JSON, if any, and decode the response
+// into result, if any
+func (store *sheetsStore) call(ctx context.Context, method, link string, body, result any) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, link, reader)
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := store.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("sheets request failed: %s %s", resp.Status, msg)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.NewDecoder(resp.Body).Decode(result)
+}
+
+func (store *sheetsStore) Close() error {
+	return nil
+}
@@ -0,0 +1,129 @@
+package sldownloader
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Length of a minisign secret key once decoded: algorithms, scrypt
+// parameters, key id, key and checksum
+const minisignSecretKeySize = 2 + 2 + 2 + 32 + 8 + 8 + 8 + ed25519.PrivateKeySize + 32
+
+var errEncryptedKey = errors.New("encrypted minisign keys are not supported, create one with minisign -G -W")
+
+// Ed25519 key signing the manifest of a run, so that mirrors of the dataset
+// can verify it comes from the official crawler
+type manifestSigner struct {
+	key ed25519.PrivateKey
+
+	// Set for minisign keys, whose signatures name the key that made them
+	keyID []byte
+}
+
+// Read a PKCS#8 PEM key, as created by openssl genpkey -algorithm ed25519, or
+// an unencrypted minisign secret key
+func loadManifestSigner(path string) (*manifestSigner, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	block, _ := pem.Decode(data)
+	if block != nil {
+		key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		edKey, ok := key.(ed25519.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("%s: not an Ed25519 key", path)
+		}
+		return &manifestSigner{key: edKey}, nil
+	}
+
+	signer, err := parseMinisignKey(data)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return signer, nil
+}
+
+func parseMinisignKey(data []byte) (*manifestSigner, error) {
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) < 2 || !strings.HasPrefix(lines[0], "untrusted comment:") {
+		return nil, errors.New("not a PEM or minisign key")
+	}
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(lines[1]))
+	if err != nil {
+		return nil, err
+	}
+	if len(raw) != minisignSecretKeySize || string(raw[:2]) != "Ed" {
+		return nil, errors.New("malformed minisign key")
+	}
+	if raw[2] != 0 || raw[3] != 0 {
+		return nil, errEncryptedKey
+	}
+
+	keyID := raw[54:62]
+	key := ed25519.PrivateKey(raw[62 : 62+ed25519.PrivateKeySize])
+	// The public half is stored along the seed, a mismatch means corruption
+	if !bytes.Equal(ed25519.NewKeyFromSeed(key.Seed()), key) {
+		return nil, errors.New("malformed minisign key")
+	}
+	return &manifestSigner{key: key, keyID: keyID}, nil
+}
+
+// Return the detached signature of the named file and the extension of its
+// file: a minisign signature for minisign keys, the raw 64 bytes otherwise
+func (signer *manifestSigner) Sign(name string, data []byte) (string, []byte) {
+	signature := ed25519.Sign(signer.key, data)
+	if signer.keyID == nil {
+		return ".sig", signature
+	}
+
+	// The legacy minisign format signs the file itself rather than its hash
+	sigLine := slices.Concat([]byte("Ed"), signer.keyID, signature)
+	trusted := fmt.Sprintf("timestamp:%d\tfile:%s", time.Now().Unix(), filepath.Base(name))
+	global := ed25519.Sign(signer.key, slices.Concat(signature, []byte(trusted)))
+
+	var out bytes.Buffer
+	fmt.Fprintln(&out, "untrusted comment: signature from sldownloader")
+	fmt.Fprintln(&out, base64.StdEncoding.EncodeToString(sigLine))
+	fmt.Fprintln(&out, "trusted comment:", trusted)
+	fmt.Fprintln(&out, base64.StdEncoding.EncodeToString(global))
+	return ".minisig", out.Bytes()
+}
+
+// Write the manifest of the files of the run, with the given extension, and
+// its signature next to it if a key is set
+func writeManifest(path string, cardSets []*CardSet, ext string, signer *manifestSigner) error {
+	manifest, err := buildManifest(cardSets, ext)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		return err
+	}
+
+	if signer == nil {
+		return nil
+	}
+	ext, signature := signer.Sign(path, data)
+	return os.WriteFile(path+ext, signature, 0644)
+}
@@ -0,0 +1,1756 @@
+package sldownloader
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-retryablehttp"
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+const (
+	// Gallery images larger than this are not processed
+	maxImageSize = 32 << 20
+	// Upper bound of a page of the StoreSearch API
+	maxProductsResponseSize = 16 << 20
+)
+
+var errResponseTooLarge = errors.New("response too large")
+
+// Stream an image to a temporary file, the caller is expected to remove it
+func downloadImage(link string) (string, error) {
+	req, err := retryablehttp.NewRequest(http.MethodGet, link, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Accept", acceptImages)
+
+	retryClient := newRetryClient()
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	// Error pages would be read as images without any number
+	if resp.StatusCode/100 != 2 {
+		return "", fmt.Errorf("unable to download %s: %s", link, resp.Status)
+	}
+	if resp.ContentLength > maxImageSize {
+		return "", errResponseTooLarge
+	}
+
+	file, err := os.CreateTemp("", "sldownloader-*")
+	if err != nil {
+		return "", err
+	}
+	defer file.Close()
+
+	n, err := io.Copy(file, io.LimitReader(resp.Body, maxImageSize+1))
+	if err == nil && n > maxImageSize {
+		err = errResponseTooLarge
+	}
+	if err != nil {
+		os.Remove(file.Name())
+		return "", err
+	}
+
+	return file.Name(), nil
+}
+
+// Write a collector number read from a gallery image or its caption as
+// Scryfall does, without the leading zeros printed on some cards
+func normalizeNumber(num string) string {
+	return strings.TrimLeft(num, "0")
+}
+
+func extractNumber(fields []string, minLen int) string {
+	for _, field := range fields {
+		// Finding any of these characters means it's over
+		switch field {
+		case "™", "©":
+			return ""
+		}
+		if len(field) > minLen {
+			_, err := strconv.Atoi(field)
+			if err == nil {
+				return field
+			}
+		}
+	}
+	return ""
+}
+
+// Return the number closest before the language code of a Japanese collector
+// line, the digits read anywhere else may come from the Japanese text
+func extractJapaneseNumber(fields []string, minLen int) string {
+	for i, field := range fields {
+		if !strings.Contains(field, "JP") {
+			continue
+		}
+		for j := i - 1; j >= 0; j-- {
+			if len(fields[j]) > minLen {
+				_, err := strconv.Atoi(fields[j])
+				if err == nil {
+					return fields[j]
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// Read the collector number out of a gallery image, the language tag tells
+// which frame is printed on the card
+func (s *Scraper) getNumberFromLink(link, lang string) (num string, err error) {
+	defer func() {
+		s.audit.Record("ocr", link, num, err)
+	}()
+
+	err = s.ocrFailures.Lookup(link)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		cacheErr := s.ocrFailures.Record(link, num, err)
+		if cacheErr != nil {
+			log.Println("Unable to record the OCR failure:", cacheErr)
+		}
+	}()
+
+	path, err := downloadImage(link)
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(path)
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	if !isCardImage(data) {
+		return "", errPackagingImage
+	}
+	data, err = ocrImage(data)
+	if err != nil {
+		return "", err
+	}
+	whitelist := ocrWhitelist
+	if lang == "jp" {
+		whitelist = ocrWhitelistJapanese
+	}
+	text, err := s.ocr.Text(data, whitelist)
+	if err != nil {
+		return "", err
+	}
+
+	fields := strings.Fields(text)
+	if lang == "jp" {
+		num = extractJapaneseNumber(fields, 2)
+	}
+	if num == "" {
+		num = extractNumber(fields, 3)
+	}
+	if num == "" {
+		num = extractNumber(fields, 2)
+	}
+
+	return normalizeNumber(num), nil
+}
+
+type CardSet struct {
+	Title       string     `json:"title"`
+	Filename    string     `json:"filename"`
+	Link        string     `json:"link"`
+	ReleaseDate string     `json:"release_date,omitempty"`
+	Cards       []CardData `json:"cards"`
+	Description string     `json:"description,omitempty"`
+	// Set for bundles and other products that are not regular drops
+	Category string `json:"category,omitempty"`
+	// Position of the drop in the Secret Lair series, when tracked
+	Sequence int `json:"sequence,omitempty"`
+	// Note of the curator who listed the drop to scrape
+	Note string `json:"note,omitempty"`
+
+	// When the drop was listed in the store, if tracked
+	Availability *availabilityWindow `json:"availability,omitempty"`
+
+	// Whether the drop is in preorder or has shipped, and when it ships, as
+	// told by its page
+	Status   string `json:"status,omitempty"`
+	ShipDate string `json:"ship_date,omitempty"`
+
+	// Prices listed by the storefront, in its currency
+	Prices []dropPrice `json:"prices,omitempty"`
+	// TCGplayer listing of the sealed drop, when resolved
+	TCGplayerProductID int    `json:"tcgplayer_product_id,omitempty"`
+	TCGplayerURL       string `json:"tcgplayer_url,omitempty"`
+	// Value of the singles of the drop, when computed
+	Value *dropValue `json:"value,omitempty"`
+
+	// Cards printed in Secret Lair for the first time, and the ones already
+	// printed by earlier drops, when classified
+	NewCards []string `json:"new_cards,omitempty"`
+	Reprints []string `json:"reprints,omitempty"`
+
+	// Raw product page and gallery image links, saved in the drop layout,
+	// with the packaging shots apart from the cards
+	page      []byte
+	images    []imagePair
+	packaging []string
+
+	// Whether no Scryfall set matched the drop
+	unmatched bool
+	// Whether the drop was just found to have shipped
+	shipped bool
+	// Changes of the cards that kept the new version from being exported
+	held *dropDiff
+	// Whether the exported file was already up to date
+	unchanged bool
+
+	// Time spent scraping and exporting the drop, when scraped
+	timings *phaseTimings
+}
+
+type CardData struct {
+	Name   string `json:"name"`
+	Number string `json:"number,omitempty"`
+	// Only set when the card is not numbered in SLD, such as some tokens
+	Set    string `json:"set,omitempty"`
+	Foil   bool   `json:"foil"`
+	Etched bool   `json:"etched"`
+	Token  bool   `json:"token"`
+	Count  int    `json:"count"`
+	// Language tag of non-English printings, such as "jp"
+	Language string `json:"language,omitempty"`
+	// Kind of non-standard inclusion, such as "oversized", empty for the
+	// regular cards
+	Component string `json:"component,omitempty"`
+	// Scryfall promo types and frame effects, such as "textured"
+	Treatments []string `json:"treatments,omitempty"`
+
+	MTGBANID     string `json:"mtgban_id,omitempty"`
+	TCGplayerID  int    `json:"tcgplayer_id,omitempty"`
+	CardmarketID int    `json:"cardmarket_id,omitempty"`
+
+	// Identifiers attached by external enrichers, keyed by their name
+	Identifiers map[string]string `json:"identifiers,omitempty"`
+
+	// Only used to pick the right product when the card is etched
+	tcgplayerEtchedID int
+
+	// Scryfall oracle identity, to tell reprints apart from new cards
+	oracleID string
+
+	// Name of the back face of double-faced cards
+	backFace string
+
+	// Scryfall prices, for the value of the drop
+	prices usdPrices
+
+	// How the number was found during the scrape
+	source numberSource
+	// Why the number may be wrong
+	flags []string
+
+	// Exported versions of the above, only set with annotations enabled
+	NumberSource string   `json:"number_source,omitempty"`
+	Flags        []string `json:"flags,omitempty"`
+}
+
+// Carry over the vendor identifiers of a Scryfall result to a scraped card
+func copyIdentifiers(card *CardData, result CardData) {
+	card.oracleID = result.oracleID
+	card.backFace = result.backFace
+	card.prices = result.prices
+	if card.Component == "" {
+		card.Component = result.Component
+	}
+	card.Treatments = result.Treatments
+	card.TCGplayerID = result.TCGplayerID
+	if card.Etched && result.tcgplayerEtchedID != 0 {
+		card.TCGplayerID = result.tcgplayerEtchedID
+	}
+}
+
+// Derive the card name, removing any special tag
+func cleanLine(cardLine string) (string, int, error) {
+	// Unicode characters
+	cardLine = strings.Replace(cardLine, " ", " ", -1)
+	cardLine = strings.Replace(cardLine, "’", "'", -1)
+	cardLine = strings.Replace(cardLine, "”", "\"", -1)
+	cardLine = strings.Replace(cardLine, "“", "\"", -1)
+	cardLine = strings.TrimSpace(cardLine)
+
+	fields := strings.Split(cardLine, "x ")
+	if len(fields) != 2 {
+		return "", 0, errors.New("unexpected line format")
+	}
+
+	num, err := strconv.Atoi(fields[0])
+	if err != nil {
+		return "", 0, errors.New("invalid number in line")
+	}
+	cardLine = strings.TrimSpace(fields[1])
+
+	// Remove anything appearing after a parenthesis
+	if strings.Contains(cardLine, "(") {
+		cardLine = strings.Split(cardLine, "(")[0]
+	}
+
+	// Remove everything before "Foil" to catch variants like Galaxy Textured etc,
+	// as long as they are before the card name
+	if strings.Contains(cardLine, "Foil") &&
+		!strings.HasSuffix(cardLine, "Foil Edition") && !strings.HasSuffix(cardLine, "Foil Etched") {
+		fields := strings.Split(cardLine, "Foil")
+		cardLine = fields[1]
+	}
+
+	// Remove this tag except for the cards with Phyrexian in them
+	if !strings.Contains(cardLine, "Tower") &&
+		!strings.Contains(cardLine, "Crusader") &&
+		!strings.Contains(cardLine, "Metamorph") &&
+		!strings.Contains(cardLine, "Reclamation") &&
+		!strings.Contains(cardLine, "Arena") &&
+		!strings.Contains(cardLine, "Unlife") {
+		cardLine = strings.Replace(cardLine, "Phyrexian", "", -1)
+	}
+
+	// Remove random prefixes from card names
+	for _, tag := range []string{
+		"Full-Text", "Full-Art", "Full-art", "Alt-Art",
+		"Reversible", "Old Frame", "Retro Frame",
+		"Poster", "Stained Glass",
+		"Foil-etched", "Etched", "Foil", "Tokens", "Token",
+		"Different", "Hand-Drawn", "Borderless",
+		"Showcase", "Left-Handed", "Edition", "cards", "Japanese",
+		"Regular Human Guy", "Ichor-E", "DFC", "Italian-language", "*",
+		"REVERSIBLE", "Display Commander", "Oversized", "Art Card",
+	} {
+		cardLine = strings.Replace(cardLine, tag, "", -1)
+		cardLine = strings.Replace(cardLine, strings.ToLower(tag), "", -1)
+	}
+
+	for _, lang := range languageTags {
+		for _, marker := range lang.Markers {
+			cardLine = strings.Replace(cardLine, marker, "", -1)
+		}
+	}
+
+	// Remove flavor names
+	if strings.Contains(cardLine, " as ") {
+		cardLine = strings.Split(cardLine, " as ")[0]
+	}
+
+	// There is no card with " by " yet...
+	if strings.Contains(cardLine, " by ") {
+		cardLine = strings.Split(cardLine, " by ")[0]
+	}
+
+	// Bob Ross Drop
+	if strings.Contains(cardLine, " with art") {
+		cardLine = strings.Split(cardLine, " with art")[0]
+	}
+
+	// Standardize DFC
+	if strings.Contains(cardLine, "//") && !strings.Contains(cardLine, " // ") {
+		cardLine = strings.Replace(cardLine, "//", " // ", -1)
+	}
+	if strings.Contains(cardLine, " / ") && !strings.Contains(cardLine, " // ") {
+		cardLine = strings.Replace(cardLine, " / ", " // ", -1)
+	}
+
+	// Only keep one face of the card
+	cardLine = strings.Split(cardLine, " // ")[0]
+
+	// Use upstream sheet name
+	cardLine = strings.Replace(cardLine, "Sticker Sheets", "Sticker sheet", -1)
+
+	// Typo
+	cardLine = strings.Replace(cardLine, "Xenegos", "Xenagos", -1)
+	cardLine = strings.Replace(cardLine, "Death Render", "Deathrender", -1)
+	cardLine = strings.Replace(cardLine, "All is Dust", "All Is Dust", -1)
+	cardLine = strings.Replace(cardLine, "Mistep", "Misstep", -1)
+	cardLine = strings.Replace(cardLine, "Triumph of Hordes", "Triumph of the Hordes", -1)
+
+	// Dashes separating a removed prefix, as in "Display Commander – Name"
+	cardLine = strings.TrimLeft(strings.TrimSpace(cardLine), "-–—")
+
+	return strings.TrimSpace(cardLine), num, nil
+}
+
+var replacerStrings = []string{
+	// Unicode characters
+	" ", " ",
+	"’", "'",
+	"‘", "'",
+	"®", "",
+	"™", "",
+	// Non-visible unicode white spaces
+	"\uFEFF", "",
+	"\u200B", "",
+	// Windows special characters
+	"<", "",
+	">", "",
+	"/", "",
+	"\\", "",
+	"*", "",
+	" - ", " ",
+	// Compatibility layer
+	" Is in ", " is in ",
+	" is In ", " is in ",
+	"Regular", "",
+	"DD ", "",
+	"Secret Lair x ", "",
+	"(English)", "",
+	"English", "",
+	" EN", "",
+	// Spaces (need to be at the end to capture as much as possible)
+	"   ", " ",
+	"  ", " ",
+}
+
+var replacer = strings.NewReplacer(replacerStrings...)
+
+// Generate two strings representing the deck name
+// The first output is a compatible, file-system safe string to be used as a filename
+// The second output is the upstream name of the deck with as few modifications as possible
+func cleanTitle(title string, policy filenamePolicy) (string, string) {
+	// Keep only the relevant portion of the name, ie we can strip "Extra Life"
+	// but not Avatar, when the name is separated by a pipe
+	if strings.Contains(title, "|") {
+		ogTitle := title
+		title = strings.Replace(title, " |", ":", 1)
+
+		if strings.Contains(ogTitle, "Extra Life") {
+			title = strings.Split(ogTitle, " | ")[0]
+			if strings.HasSuffix(ogTitle, "Foil Edition") {
+				title += " Foil Edition"
+			}
+		}
+	}
+
+	// Remove pricing from the title
+	title = strings.Split(title, " $")[0]
+
+	// Clean up!
+	title = replacer.Replace(title)
+
+	// "Secret Lair High" needs to stay
+	if !strings.Contains(title, "High") {
+		title = strings.Replace(title, "Secret Lair ", "", 1)
+	}
+
+	// Fallout has too many dots and makes searching for it harder
+	title = strings.Replace(title, "S.P.E.C.I.A.L.", "SPECIAL", 1)
+
+	// Foil
+	if strings.HasSuffix(title, "Foil") {
+		title += " Edition"
+	}
+
+	originalName := strings.TrimSpace(title)
+	filename := sanitizeFilename(title, policy)
+
+	return filename, originalName
+}
+
+// In case of error, the input cards is returned as is, so this fuction can be
+// reused in a loop multiple times
+func processLine(cards []CardData, line string) ([]CardData, error) {
+	var card CardData
+
+	if line == "" {
+		return cards, nil
+	}
+
+	cardLine, num, err := cleanLine(line)
+	if err != nil {
+		return cards, err
+	}
+
+	card.Foil = strings.Contains(strings.ToLower(line), "foil")
+	card.Etched = strings.Contains(strings.ToLower(line), "etched")
+	card.Token = strings.Contains(strings.ToLower(line), "token")
+	card.Language = lineLanguage(line)
+	card.Component = lineComponent(line)
+	card.Name = cardLine
+	card.Count = num
+
+	if strings.Contains(line, "Different") {
+		for i := 0; i < num; i++ {
+			card.Count = 1
+			cards = append(cards, card)
+		}
+	} else {
+		// Check if the card was already inserted, if so increase count, else just add it
+		idx := -1
+		for i := range cards {
+			if cards[i].Name == card.Name && cards[i].Component == card.Component {
+				idx = i
+				break
+			}
+		}
+		if idx != -1 {
+			cards[idx].Count += num
+		} else {
+			cards = append(cards, card)
+		}
+	}
+
+	return cards, nil
+}
+
+// Follow each double-faced card with an entry for its back face
+func addBackFaces(cards []CardData) []CardData {
+	var out []CardData
+	for _, card := range cards {
+		out = append(out, card)
+		if card.backFace == "" || !strings.HasSuffix(card.Number, "a") {
+			continue
+		}
+		back := card
+		back.Name = card.backFace
+		back.Number = baseNumber(card.Number) + "b"
+		back.backFace = ""
+		out = append(out, back)
+	}
+	return out
+}
+
+// Merge the identical printings of a list into a single entry with their total
+// count, preserving the order of their first appearance
+func aggregateCards(cards []CardData) []CardData {
+	var out []CardData
+	for _, card := range cards {
+		idx := slices.IndexFunc(out, func(other CardData) bool {
+			return other.Name == card.Name && other.Number == card.Number &&
+				other.Set == card.Set && other.Foil == card.Foil &&
+				other.Etched == card.Etched && other.Token == card.Token &&
+				other.Language == card.Language && other.Component == card.Component
+		})
+		if idx < 0 {
+			out = append(out, card)
+			continue
+		}
+		out[idx].Count += card.Count
+	}
+	return out
+}
+
+// Strip the parts of a drop title that Scryfall doesn't use in its headers
+func headerTitle(title string) string {
+	title = strings.ReplaceAll(title, " Foil Edition", "")
+	title = strings.ReplaceAll(title, " Raised", "")
+	title = strings.ReplaceAll(title, " Galaxy", "")
+	return title
+}
+
+// Copy the numbers of the Scryfall results over the cards with the same name.
+// If the contents differ, Scryfall is trusted and its results are used instead.
+func assignNumbers(cards, results []CardData) []CardData {
+	if len(results) != len(cards) {
+		for i := range results {
+			results[i].Foil = cards[0].Foil
+			results[i].Etched = cards[0].Etched
+			results[i].Language = cards[0].Language
+			results[i].Count = 1
+			results[i].source = sourceScryfall
+			copyIdentifiers(&results[i], results[i])
+		}
+		return results
+	}
+
+	for i := range cards {
+		for j := range results {
+			if results[j].Number != "" && cards[i].Name == results[j].Name {
+				cards[i].Number = results[j].Number
+				cards[i].source = sourceScryfall
+				copyIdentifiers(&cards[i], results[j])
+
+				// Reset so we can skip on reuse
+				results[j].Number = ""
+				break
+			}
+		}
+	}
+
+	// Names spelled differently in the store take the remaining results, by
+	// fuzzy name first, then in Scryfall order when the leftovers line up
+	var unmatched []int
+	for i := range cards {
+		if cards[i].Number != "" {
+			continue
+		}
+		j := slices.IndexFunc(results, func(result CardData) bool {
+			return result.Number != "" &&
+				(fuzzy.MatchNormalizedFold(cards[i].Name, result.Name) ||
+					fuzzy.MatchNormalizedFold(result.Name, cards[i].Name))
+		})
+		if j < 0 {
+			unmatched = append(unmatched, i)
+			continue
+		}
+		assignResult(&cards[i], &results[j])
+		cards[i].flag(flagFuzzyName)
+	}
+
+	var leftovers []int
+	for j := range results {
+		if results[j].Number != "" {
+			leftovers = append(leftovers, j)
+		}
+	}
+	if len(leftovers) == len(unmatched) {
+		for k, i := range unmatched {
+			assignResult(&cards[i], &results[leftovers[k]])
+			cards[i].flag(flagPositional)
+		}
+	}
+
+	return cards
+}
+
+// Number a card from a Scryfall result whose name differs, which is used up
+func assignResult(card *CardData, result *CardData) {
+	log.Printf("Matched %s to %s %s", card.Name, result.Name, result.Number)
+	card.Name = result.Name
+	card.Number = result.Number
+	card.source = sourceScryfall
+	copyIdentifiers(card, *result)
+	result.Number = ""
+}
+
+// Extract the marketing blurb of the drop, as paragraphs separated by an
+// empty line, falling back to the page summary
+func productDescription(doc *goquery.Document) string {
+	var paragraphs []string
+	doc.Find(`div[id="collapse1"] p`).Each(func(_ int, s *goquery.Selection) {
+		text := strings.Join(strings.Fields(s.Text()), " ")
+		if text != "" {
+			paragraphs = append(paragraphs, text)
+		}
+	})
+	if len(paragraphs) == 0 {
+		content, _ := doc.Find(`meta[name="description"]`).Attr("content")
+		return strings.TrimSpace(content)
+	}
+	return strings.Join(paragraphs, "\n\n")
+}
+
+// A product going through the stages of a scrape
+type productScrape struct {
+	scraper *Scraper
+	cardSet CardSet
+	doc     *goquery.Document
+	cards   []CardData
+	doOCR   bool
+	// Problems met so far that did not stop the scrape
+	warnings []ScrapeWarning
+	// Language tag of the whole product, such as "jp" for the Japanese drops
+	language string
+	// Names of the cards in the order of the store list
+	listed []string
+	// Gallery images contradicting the numbers, when verified
+	mismatches []imageMismatch
+	// Release date given by the store, trusted over the one of the page
+	releaseDate string
+}
+
+// Download the product page
+func (s *Scraper) fetchProduct(link string) (*productScrape, error) {
+	timings := newPhaseTimings()
+	defer timings.observe("fetch", time.Now())
+
+	page, err := s.fetchProductPage(link)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(page))
+	if err != nil {
+		return nil, err
+	}
+
+	ps := &productScrape{
+		scraper: s,
+		doc:     doc,
+		doOCR:   s.OCR,
+	}
+	ps.cardSet.Link = link
+	ps.cardSet.page = page
+	ps.cardSet.timings = timings
+	return ps, nil
+}
+
+// Read the title, description, gallery and card list of the product
+func (ps *productScrape) parse() error {
+	doc := ps.doc
+	cardSet := &ps.cardSet
+	defer cardSet.timings.observe("parse", time.Now())
+
+	doc.Find(`figure a`).Each(func(_ int, s *goquery.Selection) {
+		imgLink, found := galleryImageLink(s)
+		if found && isPackagingImage(s) {
+			cardSet.packaging = append(cardSet.packaging, imgLink)
+		}
+	})
+
+	title := doc.Find(`h1[class="product-title"]`).Text()
+	cardSet.Filename, cardSet.Title = cleanTitle(title, ps.scraper.filenames)
+	cardSet.Description = productDescription(doc)
+	cardSet.ReleaseDate = pageReleaseDate(doc)
+	cardSet.Status, cardSet.ShipDate = pageStatus(doc, time.Now())
+	cardSet.Prices = pagePrices(doc)
+	ps.language = productLanguage(title)
+
+	log.Println(cardSet.Title)
+
+	var cards []CardData
+	var err error
+	doc.Find(`div[class="force-overflow"] ul li`).Each(func(_ int, s *goquery.Selection) {
+		line := s.Text()
+		cards, err = processLine(cards, line)
+		if err != nil {
+			ps.warn(warnSkippedLine, "%s - %s", line, err.Error())
+		}
+	})
+
+	if len(cards) == 0 {
+		// Fallback if there were no bullet points
+		productInfo, _ := doc.Find(`div[id="collapse2"] div[class="force-overflow"] p[class="product-information"]`).Html()
+		for _, line := range strings.Split(productInfo, "<br/>") {
+			cards, err = processLine(cards, line)
+			if err != nil {
+				ps.warn(warnSkippedLine, "%s - %s", line, err.Error())
+			}
+		}
+	}
+
+	if len(cards) == 0 {
+		return errors.New("no cards found")
+	}
+
+	for i := range cards {
+		if cards[i].Language == "" {
+			cards[i].Language = ps.language
+		}
+	}
+	cardSet.images = pairImageLinks(galleryPairs(doc, len(cards)))
+	for _, card := range cards {
+		ps.listed = append(ps.listed, card.Name)
+	}
+
+	ps.cards = cards
+	return nil
+}
+
+// Number the cards from the Scryfall set matching the title of the drop
+func (ps *productScrape) match() error {
+	cards := ps.cards
+	cardSet := &ps.cardSet
+	scraper := ps.scraper
+	ctx := scraper.context()
+
+	// Set when a stage keeps failing, so that no half-numbered file is written
+	var stageErr error
+
+	cleanTitle := headerTitle(cardSet.Title)
+	if scraper.SkipScryfall {
+		// Keep the store order, which is the one of the gallery
+		ps.doOCR = true
+		cardSet.unmatched = true
+		return nil
+	}
+	alias := scraper.alias(cleanTitle)
+	matchHeaders := func(headers []scryfallHeader) bool {
+		// Aliases are exact and take precedence over fuzzy matching
+		if alias != "" {
+			headers = slices.DeleteFunc(slices.Clone(headers), func(header scryfallHeader) bool {
+				return !strings.EqualFold(alias, header.Title)
+			})
+		} else {
+			headers = rankHeaders(cleanTitle, headers)
+		}
+		for _, header := range headers {
+			var results []CardData
+			start := time.Now()
+			err := scraper.retry("scryfall search", func() (err error) {
+				results, err = searchURI(ctx, header.URI, ps.language)
+				return err
+			})
+			cardSet.timings.observe("scryfall", start)
+			if err != nil {
+				log.Println(err.Error())
+				if isStageFailure(err) {
+					stageErr = err
+				}
+				continue
+			}
+
+			log.Printf("Found %d possible card numbers", len(results))
+			if len(results) != len(cards) {
+				ps.warn(warnMismatch, "%s lists %d cards but Scryfall has %d, we trust Scryfall", cardSet.Title, len(cards), len(results))
+			}
+			cards = assignNumbers(cards, results)
+			return true
+		}
+		return false
+	}
+
+	start := time.Now()
+	headerList, err := scraper.headers.Load(ctx)
+	cardSet.timings.observe("scryfall", start)
+	if err != nil {
+		return err
+	}
+	foundMatch := matchHeaders(headerList)
+	if !foundMatch {
+		// The drop may be newer than the persisted headers
+		start := time.Now()
+		headerList, refreshed, err := scraper.headers.Refresh(ctx)
+		cardSet.timings.observe("scryfall", start)
+		if err != nil {
+			log.Println(err)
+		} else if refreshed {
+			foundMatch = matchHeaders(headerList)
+		}
+	}
+	if foundMatch {
+		// Another set matched, the one that failed was not needed
+		stageErr = nil
+	} else if stageErr != nil {
+		return stageErr
+	}
+	if !foundMatch {
+		// Narrow the search down to the drop's release date or numbers
+		ps.cards = cards
+		foundMatch = ps.windowSearch() == 0
+	}
+	if !foundMatch {
+		ps.warn(warnUnmatched, "%s was not found, will try OCR", cleanTitle)
+		ps.doOCR = true
+		cardSet.unmatched = true
+	}
+
+	sort.Slice(cards, func(i, j int) bool {
+		return cards[i].Number < cards[j].Number
+	})
+
+	ps.cards = cards
+	return nil
+}
+
+// Find the numbers still missing from the gallery, from the alt texts and
+// captions of the images and from their OCR when enabled, and from the
+// numbers of the other cards
+func (ps *productScrape) recoverNumbers() error {
+	doc := ps.doc
+	cards := ps.cards
+	timings := ps.cardSet.timings
+	scraper := ps.scraper
+	ctx := scraper.context()
+
+	// Set when a stage keeps failing, so that no half-numbered file is written
+	var stageErr error
+
+	// Sometimes pages show both the front and the back of some cards,
+	// but we're interested in only the front to grab the number
+	fronts := galleryFronts(doc, len(cards))
+	captioned := slices.ContainsFunc(fronts, func(s *goquery.Selection) bool {
+		return galleryTextNumber(s) != ""
+	})
+
+	if ps.doOCR || captioned {
+		// Images assigned to a different card than the one at their position
+		reordered := 0
+
+		// Position of the card of the next image, the packaging shots found
+		// once downloaded don't take one
+		pos := 0
+
+		// Find numbers by pulling images and OCR numbers out
+		for _, s := range fronts {
+			if pos >= len(cards) {
+				ps.warn(warnGallery, "Found more images than loaded cards, something may be off")
+				break
+			}
+			i := pos
+			pos++
+
+			if cards[i].Number != "" {
+				continue
+			}
+
+			imgLink, found := galleryImageLink(s)
+			if !found {
+				continue
+			}
+
+			// The text around the image is free, unlike downloading it
+			num := galleryTextNumber(s)
+			source := sourceCaption
+			var err error
+			if num == "" {
+				if !ps.doOCR {
+					continue
+				}
+				source = sourceOCR
+				start := time.Now()
+				err = scraper.retry("ocr", func() (err error) {
+					num, err = scraper.getNumberFromLink(imgLink, ps.language)
+					return err
+				})
+				timings.observe("ocr", start)
+			}
+			if errors.Is(err, errPackagingImage) {
+				log.Println(imgLink, "shows the packaging, skipping it")
+				pos--
+				continue
+			}
+			if err != nil {
+				ps.warn(warnOCR, "%s %v", imgLink, err)
+				if isStageFailure(err) {
+					stageErr = err
+					break
+				}
+				continue
+			}
+
+			if scraper.SkipScryfall {
+				// Nothing to validate the number against
+				cards[i].Number = num
+				cards[i].source = source
+				continue
+			}
+
+			var res []CardData
+			start := time.Now()
+			err = scraper.retry("validation", func() (err error) {
+				res, err = search(ctx, languageQuery(fmt.Sprintf("%s cn:%s", cards[i].Name, num), ps.language))
+				return err
+			})
+			timings.observe("scryfall", start)
+			if isStageFailure(err) {
+				stageErr = err
+				break
+			}
+			if err != nil || len(res) == 0 {
+				// The number may belong to another card of the list
+				start := time.Now()
+				j, owner, ownerErr := scraper.numberOwner(ctx, cards, num, ps.language)
+				timings.observe("scryfall", start)
+				if isStageFailure(ownerErr) {
+					stageErr = ownerErr
+					break
+				}
+				if j < 0 {
+					ps.warn(warnValidation, "%s is not %s, validation failed: %v", num, cards[i].Name, err)
+					continue
+				}
+				log.Printf("Image %d shows %s, not %s", i+1, cards[j].Name, cards[i].Name)
+				reordered++
+				i = j
+				res = []CardData{owner}
+				cards[i].flag(flagReassigned)
+			}
+
+			cards[i].Number = num
+			cards[i].source = source
+			copyIdentifiers(&cards[i], res[0])
+		}
+
+		if reordered > 0 {
+			ps.warn(warnGallery, "The gallery order differs from the card list, %d images were reassigned", reordered)
+		}
+	}
+
+	// Tokens may not be numbered within the drop set
+	if !scraper.SkipScryfall {
+		start := time.Now()
+		resolveTokens(ctx, cards)
+		timings.observe("scryfall", start)
+	}
+
+	// Validate numbers and backfill if needed
+	foundNum := 0
+	for _, card := range cards {
+		if card.Number != "" {
+			foundNum++
+		}
+	}
+	if foundNum != len(cards) && stageErr == nil {
+		log.Println("Couldn't parse all images, trying to backfill...")
+
+		fit, found := fitSequence(cards)
+		if found {
+			log.Printf("Backfilling from %d of %d numbers (%.0f%% confidence)",
+				fit.Support, fit.Anchors, 100*fit.Confidence())
+			for _, i := range fit.Outliers {
+				log.Println("Ignoring", cards[i].Name, cards[i].Number, "which is out of sequence")
+				cards[i].flag(flagOutOfSequence)
+			}
+
+			backfilled := 0
+			for j := range cards {
+				if cards[j].Number != "" {
+					continue
+				}
+				if fit.Offset+j <= 0 {
+					continue
+				}
+				num := fmt.Sprint(fit.Offset + j)
+				if scraper.SkipScryfall {
+					cards[j].Number = num
+					cards[j].source = sourceBackfill
+					cards[j].flag(flagLowConfidence)
+					backfilled++
+					continue
+				}
+
+				var res []CardData
+				start := time.Now()
+				err := scraper.retry("validation", func() (err error) {
+					res, err = search(ctx, languageQuery(fmt.Sprintf("%s cn:%s", cards[j].Name, num), ps.language))
+					return err
+				})
+				timings.observe("scryfall", start)
+				if isStageFailure(err) {
+					stageErr = err
+					break
+				}
+				if err != nil || len(res) == 0 {
+					ps.warn(warnValidation, "%s is not %s, validation failed: %v", num, cards[j].Name, err)
+					continue
+				}
+				cards[j].Number = num
+				cards[j].source = sourceBackfill
+				copyIdentifiers(&cards[j], res[0])
+				if fit.Confidence() < backfillConfidenceThreshold {
+					cards[j].flag(flagLowConfidence)
+				}
+				backfilled++
+			}
+			if backfilled > 0 {
+				ps.warn(warnBackfill, "%d numbers were backfilled (%.0f%% confidence)", backfilled, 100*fit.Confidence())
+			}
+		} else {
+			log.Println("...worth a shot")
+		}
+	}
+
+	if stageErr != nil {
+		return stageErr
+	}
+
+	missing := 0
+	for _, card := range cards {
+		if card.Number == "" {
+			missing++
+		}
+	}
+	if missing > 0 {
+		ps.warn(warnMissing, "%d of %d cards have no number", missing, len(cards))
+	}
+
+	if scraper.VerifyImages {
+		ps.cards = cards
+		ps.verifyImages()
+	}
+
+	ps.cardSet.Cards = cards
+	return nil
+}
+
+// Scrape the product at link, narrowing the search of the cards Scryfall
+// doesn't match down to releaseDate rather than the date of the page when set
+func (s *Scraper) scrapeProduct(link, releaseDate string) (*ScrapeResult, error) {
+	ps, err := s.fetchProduct(link)
+	if err != nil {
+		return nil, err
+	}
+	ps.releaseDate = releaseDate
+
+	err = ps.parse()
+	if err == nil {
+		err = ps.match()
+	}
+	if err == nil {
+		err = ps.recoverNumbers()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return ps.result(), nil
+}
+
+// Format a card in the decklist line format, without the trailing newline
+func formatCard(card CardData) string {
+	if card.Number != "" {
+		card.Number = ":" + card.Number
+	}
+	if card.Set == "" {
+		card.Set = "SLD"
+	}
+	line := fmt.Sprintf("%d [%s%s] %s", card.Count, card.Set, card.Number, card.Name)
+	if card.Foil {
+		line += " [foil]"
+	}
+	if card.Etched {
+		line += " [etched]"
+	}
+	if card.Token {
+		line += " [token]"
+	}
+	if card.Component != "" {
+		line += " [" + card.Component + "]"
+	}
+	if card.Language != "" {
+		line += " [" + card.Language + "]"
+	}
+	return line
+}
+
+// Outcome of writing a file
+type writeResult int
+
+const (
+	fileCreated writeResult = iota
+	fileUpdated
+	fileUnchanged
+	// The existing file was kept, the new version changing its cards
+	fileHeld
+)
+
+func (r writeResult) String() string {
+	switch r {
+	case fileCreated:
+		return "created"
+	case fileUpdated:
+		return "updated"
+	case fileHeld:
+		return "held"
+	}
+	return "unchanged"
+}
+
+// Ignore line endings and trailing spaces when comparing file contents
+func normalizeContent(data []byte) string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n") {
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// Write the card set in the format to filename (with the extension of the
+// format) or stdout if empty. Existing files with the same content are left
+// untouched.
+func dumpCards(cardSet *CardSet, filename string, format outputFormat) (writeResult, error) {
+	var buf bytes.Buffer
+	err := format.Write(&buf, cardSet)
+	if err != nil {
+		return 0, err
+	}
+
+	if filename == "" {
+		_, err = io.Copy(os.Stdout, &buf)
+		return fileCreated, err
+	}
+
+	filename = filename + format.Ext
+
+	result := fileCreated
+	old, err := os.ReadFile(filename)
+	if err == nil {
+		if normalizeContent(old) == normalizeContent(buf.Bytes()) {
+			log.Printf("Unchanged '%s' (%s)", filename, cardSet.ReleaseDate)
+			return fileUnchanged, nil
+		}
+		result = fileUpdated
+	}
+
+	err = os.WriteFile(filename, buf.Bytes(), 0644)
+	if err != nil {
+		return 0, err
+	}
+
+	if result == fileCreated {
+		log.Printf("Created '%s' (%s)", filename, cardSet.ReleaseDate)
+	} else {
+		log.Printf("Updated '%s' (%s)", filename, cardSet.ReleaseDate)
+	}
+
+	return result, nil
+}
+
+// Settings shared by every crawl of a run
+type crawlOptions struct {
+	Scraper   *Scraper
+	Format    outputFormat
+	Annotate  bool
+	Audit     *auditLog
+	Feed      string
+	Stores    []storage
+	Notifiers []notifier
+	Releaser  *githubReleaser
+	Manifest  string
+	Signer    *manifestSigner
+	Enrichers []enricher
+	JSON      bool
+	Color     bool
+	Layout    string
+	Pending   string
+	LockWait  time.Duration
+
+	Availability   string
+	IncludeBundles bool
+	WikiCheck      bool
+	SplitTokens    bool
+	SplitFinishes  bool
+	AcceptChanges  bool
+	SkipComponents []string
+	Aggregate      bool
+	Reprints       bool
+	EV             bool
+	Failures       string
+	StageWorkers   map[string]int
+	BothFaces      bool
+	OnlyMissing    bool
+	Newest         bool
+	MaxPages       int
+	Preorders      string
+	FilenameMap    string
+	Combined       string
+	Sealed         string
+	VerifyImages   string
+	NeedsAttention string
+	Sequence       bool
+	// Where the products are discovered, the store search API by default
+	Sources []productSource
+}
+
+// The first source of the products, which the crawl pages go through
+func (opts crawlOptions) catalog() productSource {
+	if len(opts.Sources) == 0 {
+		return storeSearchSource{Config: defaultScalefast, Audit: opts.Audit}
+	}
+	return opts.Sources[0]
+}
+
+// The context of the requests of the crawl, recorded to the audit log
+func (opts crawlOptions) context() context.Context {
+	return withAuditLog(context.Background(), opts.Audit)
+}
+
+// The files the card set is written to, its tokens apart and the other cards
+// by finish if requested, the main file first
+func splitCardSet(cardSet *CardSet, filename string, opts crawlOptions) ([]*CardSet, []string) {
+	cards, tokens := cardSet, &CardSet{}
+	if opts.SplitTokens {
+		cards, tokens = splitTokens(cardSet)
+	}
+	parts, suffixes := []*CardSet{cards}, []string{""}
+	if opts.SplitFinishes {
+		parts, suffixes = splitFinishes(cards)
+	}
+	if len(tokens.Cards) > 0 {
+		parts = append(parts, tokens)
+		suffixes = append(suffixes, " Tokens")
+	}
+
+	var filenames []string
+	for _, suffix := range suffixes {
+		filenames = append(filenames, filename+suffix)
+	}
+	return parts, filenames
+}
+
+// Write the parts of the card set to their files, returning the result of
+// the main file
+func dumpSplitCards(parts []*CardSet, filenames []string, opts crawlOptions) (writeResult, error) {
+	var result writeResult
+	for i, part := range parts {
+		partResult, err := dumpCards(part, filenames[i], opts.Format)
+		if filenames[i] != "" {
+			opts.Audit.Record("write_file", filenames[i]+opts.Format.Ext, partResult.String(), err)
+		}
+		if err != nil {
+			return 0, err
+		}
+		if i == 0 {
+			result = partResult
+		}
+	}
+	return result, nil
+}
+
+// Write the card set to its destinations
+func exportCardSet(cardSet *CardSet, filename string, opts crawlOptions) (writeResult, error) {
+	if opts.Annotate {
+		annotate(cardSet.Cards)
+	}
+	if opts.Aggregate {
+		cardSet.Cards = aggregateCards(cardSet.Cards)
+	}
+	if opts.BothFaces {
+		cardSet.Cards = addBackFaces(cardSet.Cards)
+	}
+	cardSet.Cards = skipComponents(cardSet.Cards, opts.SkipComponents)
+
+	for _, enricher := range opts.Enrichers {
+		err := enricher.Enrich(opts.context(), cardSet)
+		if err != nil {
+			log.Println("Unable to enrich card set:", err)
+		}
+	}
+
+	// The split files are copies, made once the cards are complete
+	parts, filenames := []*CardSet{cardSet}, []string{filename}
+	if (opts.SplitTokens || opts.SplitFinishes) && filename != "" {
+		parts, filenames = splitCardSet(cardSet, filename, opts)
+	}
+	if filename != "" {
+		if !opts.AcceptChanges {
+			held, err := holdRegressions(cardSet, parts, filenames, opts.Format)
+			if err != nil {
+				return 0, err
+			}
+			if held {
+				return fileHeld, nil
+			}
+		}
+		// Any version kept from an earlier run is outdated now
+		for _, name := range filenames {
+			os.Remove(name + opts.Format.Ext + ".new")
+		}
+	}
+
+	dropLayout := opts.Layout == layoutDrop && filename != ""
+	if dropLayout {
+		err := os.MkdirAll(filepath.Dir(filename), 0755)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	result, err := dumpSplitCards(parts, filenames, opts)
+	if err != nil {
+		return 0, err
+	}
+	cardSet.unchanged = result == fileUnchanged
+	recordExportedNumbers(cardSet)
+
+	// The identifiers don't fit the txt format, so they go in a parallel JSON
+	// file, unless the drops are exported as JSON already
+	if (opts.JSON || len(opts.Enrichers) > 0 || dropLayout) && opts.Format.Ext != ".json" {
+		err = writeJSONFile(cardSet, filename)
+		if filename != "" {
+			opts.Audit.Record("write_file", filename+".json", "written", err)
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	if dropLayout {
+		err = writeDropArtifacts(filepath.Dir(filename), cardSet)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	for _, store := range opts.Stores {
+		err = store.SaveCardSet(context.Background(), cardSet)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return result, nil
+}
+
+// Scrape every product of the catalog starting from the given page, and
+// return the page that a later crawl can start from
+func crawl(page int, opts crawlOptions) (int, error) {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return page, err
+	}
+	defer release()
+
+	resetSearchCache()
+	resetExportedNumbers(opts.Format)
+
+	_, err = opts.Scraper.loadHeaders(opts.context())
+	if err != nil {
+		return page, errors.New("unable to query scryfall")
+	}
+
+	var pending *pendingQueue
+	if opts.Pending != "" {
+		pending, err = loadPendingQueue(opts.Pending)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	filenames, err := loadFilenameRegistry(opts.FilenameMap, opts.Format.Ext)
+	if err != nil {
+		return page, err
+	}
+
+	var preorders *preorderQueue
+	if opts.Preorders != "" {
+		preorders, err = loadPreorderQueue(opts.Preorders)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	var failures *failureQueue
+	if opts.Failures != "" {
+		failures, err = loadFailureQueue(opts.Failures)
+		if err != nil {
+			return page, err
+		}
+	}
+
+	var availability *availabilityTracker
+	if opts.Availability != "" {
+		availability, err = loadAvailability(opts.Availability)
+		if err == nil {
+			err = availability.Update(opts.catalog())
+		}
+		if err == nil {
+			err = availability.Save()
+		}
+		if err != nil {
+			log.Println("Unable to track availability:", err)
+			availability = nil
+		}
+	}
+
+	var series *seriesTracker
+	if opts.Sequence {
+		series, err = loadSeries([]string{outputDir})
+		if err != nil {
+			return page, err
+		}
+	}
+
+	var cardSets, held []*CardSet
+	var scraped []*ScrapeResult
+	results := map[writeResult]int{}
+
+	// Products already exported are skipped when catching up, and end the
+	// crawl of the newest ones
+	var known map[string]bool
+	if opts.OnlyMissing || opts.Newest {
+		known, err = exportedProducts(opts)
+		if err != nil {
+			return page, err
+		}
+		log.Println(len(known), "products already exported")
+	}
+
+	timings := newPhaseTimings()
+	defer processTimings.add(timings)
+
+	next := make(chan int, 1)
+	items, metrics := scrapePipeline(page, opts, known, timings, next)
+	for item := range items {
+		link := item.link
+		releaseDate := item.releaseDate
+		category := item.category
+		product := item.product
+
+		// Products that failed took time as well
+		if item.scrape != nil {
+			timings.add(item.scrape.cardSet.timings)
+		}
+
+		var cardSet *CardSet
+		err := item.err
+		if err == nil {
+			pageDate := item.scrape.cardSet.ReleaseDate
+			if releaseDate != "" && pageDate != "" && releaseDate != pageDate {
+				item.scrape.warn(warnReleaseDate, "Release date of %s differs between the store (%s) and its page (%s)",
+					item.scrape.cardSet.Title, releaseDate, pageDate)
+			}
+			result := item.scrape.result()
+			cardSet = result.CardSet
+			scraped = append(scraped, result)
+		}
+		if err != nil && category != "" && len(product.Descriptions) > 0 {
+			// Parsing special products is best effort, but they are still recorded
+			log.Println("page", item.page, "-", err)
+			cardSet = &CardSet{Link: link}
+			cardSet.Filename, cardSet.Title = cleanTitle(product.Descriptions[0].Title, opts.Scraper.filenames)
+		} else if err != nil {
+			log.Println("page", item.page, "-", err)
+			opts.notifyFailure(link, err)
+			if failures != nil {
+				title := ""
+				if len(product.Descriptions) > 0 {
+					title = product.Descriptions[0].Title
+				}
+				failures.Add(link, title, releaseDate, errorClass(err), err)
+			}
+			continue
+		}
+
+		// The store API is trusted over the page, unless it has no date
+		if releaseDate != "" {
+			cardSet.ReleaseDate = releaseDate
+		}
+		cardSet.Category = category
+		if availability != nil {
+			cardSet.Availability = availability.Window(product.ProductID)
+		}
+		if opts.Layout == layoutDrop {
+			cardSet.Filename = dropOutputName(cardSet)
+		}
+		cardSet.Filename = filenames.Resolve(cardSet.Filename, link, cardSet.ReleaseDate)
+		if series != nil {
+			series.Place(cardSet)
+		}
+		printCardSet(os.Stderr, cardSet, opts.Color)
+
+		opts.checkRegistry(cardSet)
+		if opts.Reprints {
+			cardSet.NewCards, cardSet.Reprints = classifyPrintings(opts.context(), cardSet)
+		}
+		if opts.EV {
+			valueDrop(opts.context(), cardSet)
+		}
+
+		if opts.WikiCheck {
+			problems, err := crossCheckWiki(context.Background(), cardSet)
+			if err != nil {
+				log.Println("Unable to cross-check with mtg.wiki:", err)
+			}
+			for _, problem := range problems {
+				log.Println("mtg.wiki disagrees:", problem)
+			}
+		}
+
+		if cardSet.timings == nil {
+			cardSet.timings = newPhaseTimings()
+		}
+		start := time.Now()
+		result, err := exportCardSet(cardSet, cardSet.Filename, opts)
+		cardSet.timings.observe("export", start)
+		timings.observe("export", start)
+		log.Println("Timings:", cardSet.timings)
+		if err != nil {
+			log.Println(err)
+			opts.notifyFailure(link, err)
+			if failures != nil {
+				failures.Add(link, cardSet.Title, releaseDate, "export", err)
+			}
+			continue
+		}
+		results[result]++
+		if result == fileHeld {
+			held = append(held, cardSet)
+			continue
+		}
+		opts.notifyDrop(cardSet)
+		if failures != nil {
+			failures.Remove(link)
+		}
+
+		if pending != nil {
+			if cardSet.unmatched {
+				pending.Add(cardSet)
+			} else {
+				pending.Remove(link)
+			}
+		}
+		if preorders != nil {
+			preorders.Update(cardSet)
+		}
+
+		cardSets = append(cardSets, cardSet)
+	}
+
+	log.Printf("Summary: %d created, %d updated, %d unchanged, %d held",
+		results[fileCreated], results[fileUpdated], results[fileUnchanged], results[fileHeld])
+	for _, cardSet := range held {
+		log.Printf("Held '%s':\n%s", cardSet.Filename, cardSet.held)
+	}
+	for _, stage := range metrics {
+		log.Println("Stage", stage)
+	}
+	log.Println("Timings:", timings)
+	if opts.VerifyImages != "" {
+		err = writeMismatchReport(opts.VerifyImages, scraped)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+	if opts.NeedsAttention != "" {
+		err = writeAttentionReport(opts.NeedsAttention, scraped)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if warnings := summarizeWarnings(scraped); warnings != "" {
+		log.Println("Warnings:", warnings)
+	}
+	reportPrintings(cardSets)
+
+	if failures != nil {
+		err = failures.Save()
+		if err != nil {
+			log.Println(err)
+		} else if len(failures.Products) > 0 {
+			log.Println(len(failures.Products), "products failed, see", opts.Failures)
+		}
+	}
+
+	if pending != nil {
+		err = pending.Save()
+		if err != nil {
+			log.Println(err)
+		} else if len(pending.Drops) > 0 {
+			log.Println(len(pending.Drops), "drops are waiting for Scryfall, see", opts.Pending)
+		}
+	}
+
+	err = filenames.Save()
+	if err != nil {
+		log.Println(err)
+	}
+
+	if preorders != nil {
+		err = preorders.Save()
+		if err != nil {
+			log.Println(err)
+		} else if len(preorders.Drops) > 0 {
+			log.Println(len(preorders.Drops), "drops are in preorder, see", opts.Preorders)
+		}
+	}
+
+	if opts.Feed != "" {
+		err = updateFeed(opts.Feed, cardSets)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if opts.Combined != "" {
+		err = appendCombined(opts.Combined, cardSets)
+		if err != nil {
+			log.Println(err)
+		}
+	}
+
+	if opts.Sealed != "" && len(cardSets) > 0 {
+		err = writeSealedContents(opts.Sealed, cardSets)
+		if err != nil {
+			log.Println("Unable to write sealed contents:", err)
+		}
+	}
+
+	if opts.Manifest != "" && len(cardSets) > 0 {
+		err = writeManifest(opts.Manifest, cardSets, opts.Format.Ext, opts.Signer)
+		if err != nil {
+			log.Println("Unable to write manifest:", err)
+		}
+	}
+
+	if opts.Releaser != nil && len(cardSets) > 0 {
+		err = opts.Releaser.Publish(context.Background(), cardSets)
+		if err != nil {
+			log.Println("Unable to publish release:", err)
+		} else {
+			log.Println("Published", len(cardSets), "drops to", opts.Releaser.Repo, opts.Releaser.Tag)
+		}
+	}
+
+	return <-next, nil
+}
+
+const maxItemsInResp = 50
+
+// The store search service and tenant of the storefront
+type scalefastConfig struct {
+	Host   string
+	UserID string
+	// Language of the titles and descriptions, and currency of the prices,
+	// which don't change the region of the store
+	Locale   string
+	Currency string
+}
+
+// The store search in English, with the prices in US dollars
+var defaultScalefast = scalefastConfig{
+	Host:     "storesearch.eu.scalefast.com",
+	UserID:   "10751401",
+	Locale:   "en_US",
+	Currency: "USD",
+}
+
+func (config scalefastConfig) searchURL(offset int) string {
+	return fmt.Sprintf("https://%s/StoreSearch?userID=%s&locale=%s&currency=%s&crit=ALL&sort=release_date&count=%d&env=prod&offset=%d",
+		config.Host, url.QueryEscape(config.UserID), url.QueryEscape(config.Locale), url.QueryEscape(config.Currency), maxItemsInResp, offset)
+}
+
+type ScalefastResponse struct {
+	Count    int                `json:"count"`
+	Total    int                `json:"total"`
+	Products []ScalefastProduct `json:"products"`
+}
+
+type ScalefastProduct struct {
+	ProductID    string                 `json:"productID"`
+	ReleaseDate  time.Time              `json:"release_date"`
+	Descriptions []ScalefastDescription `json:"descriptions"`
+}
+
+type ScalefastDescription struct {
+	Lang  string `json:"lang"`
+	Title string `json:"title"`
+}
+
+func (config scalefastConfig) getProducts(offset int) (*ScalefastResponse, error) {
+	var response ScalefastResponse
+	err := fetchCatalog(config.searchURL(offset), &response)
+	if err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// Decode a page of the store search results into v
+func fetchCatalog(link string, v any) error {
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Get(link)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return json.NewDecoder(io.LimitReader(resp.Body, maxProductsResponseSize)).Decode(v)
+}
@@ -0,0 +1,108 @@
+package sldownloader
+
+import (
+	"bytes"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Listing pages are followed up to this many, in case the page parameter is
+// ignored and the same products keep coming
+const maxArchivePages = 50
+
+// A listing of the products of the store
+type productSource interface {
+	Name() string
+	// Products of the page, counted from zero, none past the last page
+	Products(page int) ([]ScalefastProduct, error)
+}
+
+// The StoreSearch API, sorted by release date
+type storeSearchSource struct {
+	Config scalefastConfig
+	// Where the requests are recorded, nil to discard them
+	Audit *auditLog
+}
+
+func (storeSearchSource) Name() string {
+	return "store search"
+}
+
+func (source storeSearchSource) Products(page int) (products []ScalefastProduct, err error) {
+	link := source.Config.searchURL(page * maxItemsInResp)
+	defer func() {
+		source.Audit.Record("fetch_catalog", link, fmt.Sprintf("%d products", len(products)), err)
+	}()
+
+	resp, err := source.Config.getProducts(page * maxItemsInResp)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Products, nil
+}
+
+// A listing page of the storefront, such as the past drops collection, which
+// sometimes shows products the API hides. Only the product links and titles
+// are known, the release date is left empty.
+type archiveSource struct {
+	URL string
+	// Fetches the listing pages like the product ones
+	Scraper *Scraper
+}
+
+func (source archiveSource) Name() string {
+	return source.URL
+}
+
+func (source archiveSource) Products(page int) ([]ScalefastProduct, error) {
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return nil, err
+	}
+	query := u.Query()
+	query.Set("page", strconv.Itoa(page+1))
+	u.RawQuery = query.Encode()
+
+	data, err := source.Scraper.fetchProductPage(u.String())
+	if err != nil {
+		return nil, err
+	}
+	doc, err := goquery.NewDocumentFromReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	return archiveProducts(doc), nil
+}
+
+// Collect the products linked from a listing page
+func archiveProducts(doc *goquery.Document) []ScalefastProduct {
+	var products []ScalefastProduct
+	seen := map[string]bool{}
+	doc.Find(`a[href*="/product/"]`).Each(func(_ int, s *goquery.Selection) {
+		href, _ := s.Attr("href")
+		id := productKey(href)
+		if id == "" || id == "product" || seen[id] {
+			return
+		}
+		seen[id] = true
+
+		title := strings.Join(strings.Fields(s.Text()), " ")
+		if title == "" {
+			title, _ = s.Attr("title")
+		}
+		if title == "" {
+			title, _ = s.Find("img").Attr("alt")
+		}
+
+		product := ScalefastProduct{ProductID: id}
+		if title != "" {
+			product.Descriptions = []ScalefastDescription{{Lang: "en", Title: title}}
+		}
+		products = append(products, product)
+	})
+	return products
+}
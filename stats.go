@@ -0,0 +1,179 @@
+package sldownloader
+
+import (
+	"cmp"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Aggregate statistics over a set of generated files
+type outputStats struct {
+	Drops          int            `json:"drops"`
+	Cards          int            `json:"cards"`
+	DropsPerYear   map[string]int `json:"drops_per_year"`
+	CardsPerFinish map[string]int `json:"cards_per_finish"`
+	// How the numbers were found, "unknown" for files without annotations
+	CardsPerSource  map[string]int `json:"cards_per_source"`
+	NumberedCards   int            `json:"numbered_cards"`
+	NumberedPercent float64        `json:"numbered_percent"`
+	MostReprinted   []reprintStat  `json:"most_reprinted"`
+}
+
+type reprintStat struct {
+	Name  string `json:"name"`
+	Drops int    `json:"drops"`
+}
+
+func cardFinish(card CardData) string {
+	switch {
+	case card.Etched:
+		return "etched"
+	case card.Foil:
+		return "foil"
+	}
+	return "nonfoil"
+}
+
+func computeStats(cardSets []*CardSet, top int) outputStats {
+	stats := outputStats{
+		Drops:          len(cardSets),
+		DropsPerYear:   map[string]int{},
+		CardsPerFinish: map[string]int{},
+		CardsPerSource: map[string]int{},
+	}
+
+	dropsPerName := map[string]int{}
+	for _, cardSet := range cardSets {
+		year := "unknown"
+		if len(cardSet.ReleaseDate) >= 4 {
+			year = cardSet.ReleaseDate[:4]
+		}
+		stats.DropsPerYear[year]++
+
+		var names []string
+		for _, card := range cardSet.Cards {
+			stats.Cards += card.Count
+			stats.CardsPerFinish[cardFinish(card)] += card.Count
+			stats.CardsPerSource[cmp.Or(card.NumberSource, "unknown")] += card.Count
+			if card.Number != "" {
+				stats.NumberedCards += card.Count
+			}
+			if !card.Token && !slices.Contains(names, card.Name) {
+				names = append(names, card.Name)
+			}
+		}
+		for _, name := range names {
+			dropsPerName[name]++
+		}
+	}
+	if stats.Cards > 0 {
+		stats.NumberedPercent = 100 * float64(stats.NumberedCards) / float64(stats.Cards)
+	}
+
+	for name, drops := range dropsPerName {
+		if drops < 2 {
+			continue
+		}
+		stats.MostReprinted = append(stats.MostReprinted, reprintStat{
+			Name:  name,
+			Drops: drops,
+		})
+	}
+	slices.SortFunc(stats.MostReprinted, func(a, b reprintStat) int {
+		if a.Drops != b.Drops {
+			return b.Drops - a.Drops
+		}
+		return strings.Compare(a.Name, b.Name)
+	})
+	if len(stats.MostReprinted) > top {
+		stats.MostReprinted = stats.MostReprinted[:top]
+	}
+
+	return stats
+}
+
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ExitOnError)
+	jsonOpt := fs.Bool("json", false, "Output the statistics as JSON")
+	topOpt := fs.Int("top", 10, "How many of the most reprinted cards to list")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader stats [-json] dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	var cardSets []*CardSet
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		cardSets = append(cardSets, cardSet)
+	}
+
+	stats := computeStats(cardSets, *topOpt)
+
+	if *jsonOpt {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(stats)
+		if err != nil {
+			log.Println(err)
+			return 1
+		}
+		return 0
+	}
+
+	fmt.Printf("Drops: %d\n", stats.Drops)
+	fmt.Printf("Cards: %d\n", stats.Cards)
+	fmt.Printf("Cards with a collector number: %d (%.1f%%)\n", stats.NumberedCards, stats.NumberedPercent)
+
+	fmt.Println()
+	fmt.Println("Drops per year:")
+	var years []string
+	for year := range stats.DropsPerYear {
+		years = append(years, year)
+	}
+	slices.Sort(years)
+	for _, year := range years {
+		fmt.Printf("  %s: %d\n", year, stats.DropsPerYear[year])
+	}
+
+	fmt.Println()
+	fmt.Println("Cards per finish:")
+	for _, finish := range []string{"nonfoil", "foil", "etched"} {
+		fmt.Printf("  %s: %d\n", finish, stats.CardsPerFinish[finish])
+	}
+
+	fmt.Println()
+	fmt.Println("Cards per number source:")
+	for _, source := range slices.Sorted(maps.Keys(stats.CardsPerSource)) {
+		fmt.Printf("  %s: %d\n", source, stats.CardsPerSource[source])
+	}
+
+	fmt.Println()
+	fmt.Println("Most reprinted cards:")
+	for _, reprint := range stats.MostReprinted {
+		fmt.Printf("  %s: %d drops\n", reprint.Name, reprint.Drops)
+	}
+
+	return 0
+}
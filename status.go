@@ -0,0 +1,162 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/PuerkitoBio/goquery"
+)
+
+// Shipping status of a drop, empty when the page doesn't tell
+const (
+	statusPreorder = "preorder"
+	statusShipped  = "shipped"
+)
+
+// Words of a page offering the drop for preorder, lowercase
+var preorderMarkers = []string{"pre-order", "preorder", "pre order"}
+
+// Tell whether the drop is still in preorder from its page, by its shipping
+// date if printed, and return that date
+func pageStatus(doc *goquery.Document, now time.Time) (status, shipDate string) {
+	shipDate = pageDateNear(doc, []string{"ship"})
+	if shipDate != "" {
+		if shipDate > now.Format(time.DateOnly) {
+			return statusPreorder, shipDate
+		}
+		return statusShipped, shipDate
+	}
+
+	text := strings.ToLower(doc.Find(`h1, button`).Text())
+	for _, marker := range preorderMarkers {
+		if strings.Contains(text, marker) {
+			return statusPreorder, ""
+		}
+	}
+	return "", ""
+}
+
+// A drop exported while in preorder, watched until it ships
+type preorderDrop struct {
+	Link        string `json:"link"`
+	Title       string `json:"title"`
+	Filename    string `json:"filename"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	ShipDate    string `json:"ship_date,omitempty"`
+}
+
+type preorderQueue struct {
+	Path  string
+	Drops []preorderDrop
+}
+
+func loadPreorderQueue(path string) (*preorderQueue, error) {
+	queue := &preorderQueue{Path: path}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return queue, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = json.Unmarshal(data, &queue.Drops)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return queue, nil
+}
+
+// Watch the drop if in preorder, stop watching it otherwise
+func (queue *preorderQueue) Update(cardSet *CardSet) {
+	queue.Drops = slices.DeleteFunc(queue.Drops, func(drop preorderDrop) bool {
+		return drop.Link == cardSet.Link
+	})
+	if cardSet.Status == statusPreorder {
+		queue.Drops = append(queue.Drops, preorderDrop{
+			Link:        cardSet.Link,
+			Title:       cardSet.Title,
+			Filename:    cardSet.Filename,
+			ReleaseDate: cardSet.ReleaseDate,
+			ShipDate:    cardSet.ShipDate,
+		})
+	}
+}
+
+func (queue *preorderQueue) Save() error {
+	data, err := json.MarshalIndent(queue.Drops, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(queue.Path, data, 0644)
+}
+
+// Scrape again the watched drops that may have shipped, matching them with
+// Scryfall anew since that is usually when their numbers become available.
+// Drops that shipped are exported and notified as such, and the number of
+// them is returned.
+func checkPreorders(path string, opts crawlOptions) (int, error) {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return 0, err
+	}
+	defer release()
+
+	queue, err := loadPreorderQueue(path)
+	if err != nil {
+		return 0, err
+	}
+
+	// Scryfall may have been updated since the previous searches
+	resetSearchCache()
+
+	today := time.Now().Format(time.DateOnly)
+	shipped := 0
+	for _, drop := range slices.Clone(queue.Drops) {
+		if drop.ShipDate > today {
+			continue
+		}
+
+		result, err := opts.Scraper.scrapeProduct(drop.Link, drop.ReleaseDate)
+		if err != nil {
+			log.Println(drop.Link, "-", err)
+			continue
+		}
+		cardSet := result.CardSet
+		if drop.ReleaseDate != "" {
+			cardSet.ReleaseDate = drop.ReleaseDate
+		}
+		cardSet.Filename = drop.Filename
+		if cardSet.Status == statusPreorder {
+			queue.Update(cardSet)
+			continue
+		}
+
+		log.Println(drop.Title, "has shipped")
+		cardSet.Status = statusShipped
+		cardSet.shipped = true
+		written, err := exportCardSet(cardSet, drop.Filename, opts)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		// Checked again until the changes are accepted
+		if written == fileHeld {
+			log.Println(drop.Title, "-", errHeld)
+			continue
+		}
+		opts.notifyDrop(cardSet)
+		queue.Update(cardSet)
+		shipped++
+	}
+
+	return shipped, queue.Save()
+}
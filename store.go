@@ -0,0 +1,62 @@
+package sldownloader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// A storage is a structured destination for scraped drops, in addition to
+// the text files, such as a database or a spreadsheet
+type storage interface {
+	SaveCardSet(ctx context.Context, cardSet *CardSet) error
+	Close() error
+}
+
+// Open the storage backend identified by the scheme of the dsn
+func openStorage(ctx context.Context, dsn string) (storage, error) {
+	switch {
+	case strings.HasPrefix(dsn, "postgres://"),
+		strings.HasPrefix(dsn, "postgresql://"):
+		return newPostgresStore(ctx, dsn)
+	}
+	return nil, errors.New("unsupported storage backend")
+}
+
+// A printing claimed by a drop whose number is already registered to a
+// different card by another drop
+type printingConflict struct {
+	Card       CardData
+	Registered string
+	DropTitle  string
+}
+
+func (conflict printingConflict) String() string {
+	return fmt.Sprintf("%s is %s in %s", formatCard(conflict.Card), conflict.Registered, conflict.DropTitle)
+}
+
+// Storages keeping a registry of every printing seen across all drops
+type printingRegistry interface {
+	PrintingConflicts(ctx context.Context, cardSet *CardSet) ([]printingConflict, error)
+}
+
+// Log any collector number of the drop already assigned to a different card,
+// which usually means the drop was matched to the wrong Scryfall header
+func (opts crawlOptions) checkRegistry(cardSet *CardSet) {
+	for _, store := range opts.Stores {
+		registry, ok := store.(printingRegistry)
+		if !ok {
+			continue
+		}
+		conflicts, err := registry.PrintingConflicts(context.Background(), cardSet)
+		if err != nil {
+			log.Println("Unable to check the printing registry:", err)
+			continue
+		}
+		for _, conflict := range conflicts {
+			log.Println("Collector number conflict:", conflict)
+		}
+	}
+}
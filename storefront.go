@@ -0,0 +1,158 @@
+package sldownloader
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const storefrontURL = "https://secretlair.wizards.com"
+
+// How many times a product page is requested again after an interstitial,
+// using the cookies it set
+const maxInterstitialRetries = 2
+
+var errInterstitial = errors.New("product page hidden behind a region, age or cookie interstitial, try preloading cookies with -cookies")
+
+// Markers of the pages the storefront shows instead of the product
+var interstitialMarkers = []string{
+	"age-gate",
+	"agegate",
+	"date of birth",
+	"verify your age",
+	"cookie-consent",
+	"cookie consent",
+	"onetrust",
+	"select your region",
+	"choose your region",
+}
+
+// Client used for product pages, keeping the cookies across requests
+func newStorefrontClient() *retryablehttp.Client {
+	// Never fails without options
+	jar, _ := cookiejar.New(nil)
+
+	retryClient := newRetryClient()
+	retryClient.HTTPClient.Jar = jar
+	return retryClient
+}
+
+// Load cookies for the storefront from a file, either exported from a browser
+// in the Netscape cookies.txt format, or with one name=value pair per line
+func loadCookies(client *retryablehttp.Client, path string) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	byURL := map[string][]*http.Cookie{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || (strings.HasPrefix(line, "#") && !strings.HasPrefix(line, "#HttpOnly_")) {
+			continue
+		}
+
+		fields := strings.Split(line, "\t")
+		if len(fields) == 7 {
+			domain := strings.TrimPrefix(fields[0], "#HttpOnly_")
+			cookie := &http.Cookie{
+				Domain: domain,
+				Path:   fields[2],
+				Secure: fields[3] == "TRUE",
+				Name:   fields[5],
+				Value:  fields[6],
+			}
+			expiration, err := strconv.ParseInt(fields[4], 10, 64)
+			if err == nil && expiration > 0 {
+				cookie.Expires = time.Unix(expiration, 0)
+			}
+			link := "https://" + strings.TrimPrefix(domain, ".") + "/"
+			byURL[link] = append(byURL[link], cookie)
+			continue
+		}
+
+		name, value, found := strings.Cut(line, "=")
+		if !found {
+			return fmt.Errorf("%s: invalid cookie line %q", path, line)
+		}
+		byURL[storefrontURL+"/"] = append(byURL[storefrontURL+"/"], &http.Cookie{
+			Name:  strings.TrimSpace(name),
+			Value: strings.TrimSpace(value),
+			Path:  "/",
+		})
+	}
+	err = scanner.Err()
+	if err != nil {
+		return err
+	}
+
+	for link, cookies := range byURL {
+		u, err := url.Parse(link)
+		if err != nil {
+			return err
+		}
+		client.HTTPClient.Jar.SetCookies(u, cookies)
+	}
+	return nil
+}
+
+func isInterstitial(page []byte) bool {
+	// Anything with a product title is good to go
+	if bytes.Contains(page, []byte(`class="product-title"`)) {
+		return false
+	}
+	lower := bytes.ToLower(page)
+	for _, marker := range interstitialMarkers {
+		if bytes.Contains(lower, []byte(marker)) {
+			return true
+		}
+	}
+	return false
+}
+
+// Download a product page, going through any interstitial the storefront
+// shows first
+func (s *Scraper) fetchProductPage(link string) (page []byte, err error) {
+	defer func() {
+		s.audit.Record("fetch_product", link, fmt.Sprintf("%d bytes", len(page)), err)
+	}()
+
+	path, saved := s.savedPages[link]
+	if saved {
+		return os.ReadFile(path)
+	}
+
+	for i := 0; ; i++ {
+		resp, err := s.storefront.Get(link)
+		if err != nil {
+			return nil, err
+		}
+		page, err = io.ReadAll(io.LimitReader(resp.Body, maxProductsResponseSize))
+		resp.Body.Close()
+		if err != nil {
+			return nil, err
+		}
+
+		if !isInterstitial(page) {
+			return page, nil
+		}
+		if i == maxInterstitialRetries {
+			return nil, errInterstitial
+		}
+		log.Println("Interstitial found, requesting the page again")
+	}
+}
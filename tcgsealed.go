@@ -0,0 +1,142 @@
+package sldownloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode"
+)
+
+const tcgplayerProductURL = "https://www.tcgplayer.com/product/"
+
+// A sealed product of the MTGJSON set file
+type mtgjsonSealedProduct struct {
+	Name        string `json:"name"`
+	ReleaseDate string `json:"releaseDate"`
+	Identifiers struct {
+		TCGplayerProductID string `json:"tcgplayerProductId"`
+	} `json:"identifiers"`
+}
+
+// Name of a drop or of a sealed product without the Secret Lair prefixes,
+// lowercase and with only letters and digits
+func sealedMatchName(name string) string {
+	name = strings.ToLower(name)
+	for _, prefix := range []string{"secret lair drop series", "secret lair drop", "secret lair x", "secret lair"} {
+		if strings.HasPrefix(name, prefix) {
+			name = name[len(prefix):]
+			break
+		}
+	}
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsLetter(r) || unicode.IsDigit(r) {
+			return r
+		}
+		return ' '
+	}, name)
+	return strings.Join(strings.Fields(name), " ")
+}
+
+// Resolve the TCGplayer product of each drop, from the sealed products that
+// MTGJSON maps to TCGplayer, so that the unopened drops can be priced
+type tcgplayerSealedResolver struct {
+	once     sync.Once
+	err      error
+	products []mtgjsonSealedProduct
+}
+
+func (r *tcgplayerSealedResolver) load() error {
+	r.once.Do(func() {
+		retryClient := newRetryClient()
+
+		resp, err := retryClient.Get(mtgjsonSLDURL)
+		if err != nil {
+			r.err = err
+			return
+		}
+		defer resp.Body.Close()
+
+		var setFile struct {
+			Data struct {
+				SealedProduct []mtgjsonSealedProduct `json:"sealedProduct"`
+			} `json:"data"`
+		}
+		err = json.NewDecoder(io.LimitReader(resp.Body, maxMTGJSONSize)).Decode(&setFile)
+		if err != nil {
+			r.err = err
+			return
+		}
+		for _, product := range setFile.Data.SealedProduct {
+			if product.Identifiers.TCGplayerProductID != "" {
+				r.products = append(r.products, product)
+			}
+		}
+		log.Println("Loaded", len(r.products), "MTGJSON sealed products")
+	})
+	return r.err
+}
+
+// Find the sealed product of the drop by name, preferring an exact match and
+// then the one released the same day
+func (r *tcgplayerSealedResolver) Resolve(cardSet *CardSet) (int, error) {
+	err := r.load()
+	if err != nil {
+		return 0, err
+	}
+
+	title := sealedMatchName(cardSet.Title)
+	var exact, partial []mtgjsonSealedProduct
+	for _, product := range r.products {
+		name := sealedMatchName(product.Name)
+		switch {
+		case name == title:
+			exact = append(exact, product)
+		case titleMatches(title, name):
+			partial = append(partial, product)
+		}
+	}
+
+	candidates := exact
+	if len(candidates) == 0 {
+		candidates = partial
+	}
+	if len(candidates) > 1 && cardSet.ReleaseDate != "" {
+		var sameDay []mtgjsonSealedProduct
+		for _, product := range candidates {
+			if product.ReleaseDate == cardSet.ReleaseDate {
+				sameDay = append(sameDay, product)
+			}
+		}
+		if len(sameDay) > 0 {
+			candidates = sameDay
+		}
+	}
+
+	switch len(candidates) {
+	case 0:
+		return 0, errors.New("no sealed product found")
+	case 1:
+		return strconv.Atoi(candidates[0].Identifiers.TCGplayerProductID)
+	}
+	var names []string
+	for _, product := range candidates {
+		names = append(names, product.Name)
+	}
+	return 0, errors.New("several sealed products match: " + strings.Join(names, ", "))
+}
+
+func (r *tcgplayerSealedResolver) Enrich(ctx context.Context, cardSet *CardSet) error {
+	id, err := r.Resolve(cardSet)
+	if err != nil {
+		log.Println(cardSet.Title, "- unable to resolve the TCGplayer product:", err)
+		return nil
+	}
+	cardSet.TCGplayerProductID = id
+	cardSet.TCGplayerURL = tcgplayerProductURL + strconv.Itoa(id)
+	return nil
+}
@@ -0,0 +1,103 @@
+package sldownloader
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Phases of a crawl whose durations are tracked, in order
+var timingPhases = []string{"store", "fetch", "parse", "scryfall", "ocr", "export"}
+
+// Time spent in each phase, for a single drop or a whole run. Phases running
+// concurrently add up, so the total may exceed the wall time of a run.
+type phaseTimings struct {
+	mu        sync.Mutex
+	durations map[string]time.Duration
+	calls     map[string]int
+}
+
+func newPhaseTimings() *phaseTimings {
+	return &phaseTimings{
+		durations: map[string]time.Duration{},
+		calls:     map[string]int{},
+	}
+}
+
+// Record the time elapsed since start in the phase
+func (timings *phaseTimings) observe(phase string, start time.Time) {
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	timings.durations[phase] += time.Since(start)
+	timings.calls[phase]++
+}
+
+// Add the timings of other, such as the ones of a drop to its run
+func (timings *phaseTimings) add(other *phaseTimings) {
+	other.mu.Lock()
+	defer other.mu.Unlock()
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+	for phase, d := range other.durations {
+		timings.durations[phase] += d
+		timings.calls[phase] += other.calls[phase]
+	}
+}
+
+func (timings *phaseTimings) String() string {
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+
+	var out []string
+	for _, phase := range timingPhases {
+		if timings.calls[phase] == 0 {
+			continue
+		}
+		out = append(out, fmt.Sprintf("%s %s", phase, timings.durations[phase].Round(time.Millisecond)))
+	}
+	if len(out) == 0 {
+		return "nothing timed"
+	}
+	return strings.Join(out, ", ")
+}
+
+// Timings of every crawl since the process started, exposed to Prometheus
+var processTimings = newPhaseTimings()
+
+// Write the timings in the Prometheus text exposition format
+func (timings *phaseTimings) writeMetrics(w io.Writer) {
+	timings.mu.Lock()
+	defer timings.mu.Unlock()
+
+	fmt.Fprintln(w, "# HELP sldownloader_phase_seconds_total Time spent in each phase of the crawls.")
+	fmt.Fprintln(w, "# TYPE sldownloader_phase_seconds_total counter")
+	for _, phase := range timingPhases {
+		fmt.Fprintf(w, "sldownloader_phase_seconds_total{phase=%q} %g\n", phase, timings.durations[phase].Seconds())
+	}
+	fmt.Fprintln(w, "# HELP sldownloader_phase_calls_total Number of times each phase of the crawls ran.")
+	fmt.Fprintln(w, "# TYPE sldownloader_phase_calls_total counter")
+	for _, phase := range timingPhases {
+		fmt.Fprintf(w, "sldownloader_phase_calls_total{phase=%q} %d\n", phase, timings.calls[phase])
+	}
+}
+
+// Serve the timings of the process on /metrics, for scheduled crawls
+func serveMetrics(addr string) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		processTimings.writeMetrics(w)
+	})
+
+	go func() {
+		log.Println("Serving metrics on", addr)
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.Println("metrics server:", err)
+		}
+	}()
+}
@@ -0,0 +1,117 @@
+package sldownloader
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Minimum score of a Scryfall header for a drop title to match it
+const titleMatchThreshold = 0.85
+
+// Words of the titles that tell nothing about the drop
+var titleStopWords = []string{"secret", "lair", "drop", "series", "x"}
+
+// Distinct words of a title, lowercase and sorted, without the stop words and
+// the subtitle after a pipe. Dots and apostrophes are dropped so that
+// "S.P.E.C.I.A.L." and "They're" are single words.
+func titleTokens(title string) []string {
+	title, _, _ = strings.Cut(title, "|")
+	title = strings.ToLower(title)
+	title = strings.NewReplacer(".", "", "'", "", "’", "").Replace(title)
+
+	var tokens []string
+	for _, word := range strings.FieldsFunc(title, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if !slices.Contains(titleStopWords, word) {
+			tokens = append(tokens, word)
+		}
+	}
+	slices.Sort(tokens)
+	return slices.Compact(tokens)
+}
+
+// Similarity of two strings from 0 to 1, from the length of their longest
+// common subsequence
+func similarity(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	if len(ra)+len(rb) == 0 {
+		return 1
+	}
+	prev := make([]int, len(rb)+1)
+	curr := make([]int, len(rb)+1)
+	for i := range ra {
+		for j := range rb {
+			if ra[i] == rb[j] {
+				curr[j+1] = prev[j] + 1
+			} else {
+				curr[j+1] = max(prev[j+1], curr[j])
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return 2 * float64(prev[len(rb)]) / float64(len(ra)+len(rb))
+}
+
+// Token set ratio of two titles: the words they share are compared to each
+// title as a whole, so that word order and extra words in either one, such
+// as an edition, don't matter
+func titleScore(a, b string) float64 {
+	ta, tb := titleTokens(a), titleTokens(b)
+	var common, onlyA, onlyB []string
+	for _, token := range ta {
+		if slices.Contains(tb, token) {
+			common = append(common, token)
+		} else {
+			onlyA = append(onlyA, token)
+		}
+	}
+	for _, token := range tb {
+		if !slices.Contains(ta, token) {
+			onlyB = append(onlyB, token)
+		}
+	}
+	if len(common) == 0 {
+		return similarity(strings.Join(ta, " "), strings.Join(tb, " "))
+	}
+
+	shared := strings.Join(common, " ")
+	withA := strings.TrimSpace(shared + " " + strings.Join(onlyA, " "))
+	withB := strings.TrimSpace(shared + " " + strings.Join(onlyB, " "))
+	return max(similarity(shared, withA), similarity(shared, withB), similarity(withA, withB))
+}
+
+// Whether a (cleaned) drop title corresponds to a Scryfall header title
+func titleMatches(title, header string) bool {
+	return titleScore(title, header) >= titleMatchThreshold
+}
+
+// Headers matching the title, the best first. Ties, as when the words of
+// several headers are all in the title, go to the closest one as a whole.
+func rankHeaders(title string, headers []scryfallHeader) []scryfallHeader {
+	type scored struct {
+		header scryfallHeader
+		score  float64
+		whole  float64
+	}
+	var matches []scored
+	for _, header := range headers {
+		score := titleScore(title, header.Title)
+		if score < titleMatchThreshold {
+			continue
+		}
+		whole := similarity(strings.Join(titleTokens(title), " "), strings.Join(titleTokens(header.Title), " "))
+		matches = append(matches, scored{header, score, whole})
+	}
+	slices.SortStableFunc(matches, func(a, b scored) int {
+		return cmp.Or(cmp.Compare(b.score, a.score), cmp.Compare(b.whole, a.whole))
+	})
+
+	var ranked []scryfallHeader
+	for _, match := range matches {
+		ranked = append(ranked, match.header)
+	}
+	return ranked
+}
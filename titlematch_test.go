@@ -0,0 +1,49 @@
+package sldownloader
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+// The titles and headers fixtures list the same drops in the same order
+func TestRankHeaders(t *testing.T) {
+	read := func(name string) []string {
+		data, err := os.ReadFile("testdata/" + name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return strings.Split(strings.TrimSpace(string(data)), "\n")
+	}
+	titles := read("titles.txt")
+	var headers []scryfallHeader
+	for _, title := range read("headers.txt") {
+		headers = append(headers, scryfallHeader{Title: title})
+	}
+
+	for i, title := range titles {
+		_, name := cleanTitle(title, defaultFilenamePolicy)
+		ranked := rankHeaders(headerTitle(name), headers)
+		if len(ranked) == 0 || ranked[0] != headers[i] {
+			t.Errorf("%q: got %v, want %q first", title, ranked, headers[i].Title)
+		}
+	}
+}
+
+func TestTitleScore(t *testing.T) {
+	tests := []struct {
+		a, b  string
+		match bool
+	}{
+		{"Secret Lair Drop Series: Bitterblossom Dreams", "Bitterblossom Dreams", true},
+		{"Dreams Bitterblossom", "Bitterblossom Dreams", true},
+		{"Street Fighter | Foil Edition", "Street Fighter", true},
+		{"Totally Spaced Out", "The Walking Dead", false},
+		{"Eldraine Wonderland", "Theros Stargazing", false},
+	}
+	for _, test := range tests {
+		if got := titleMatches(test.a, test.b); got != test.match {
+			t.Errorf("%q and %q: got match %v, want %v (score %.2f)", test.a, test.b, got, test.match, titleScore(test.a, test.b))
+		}
+	}
+}
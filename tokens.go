@@ -0,0 +1,63 @@
+package sldownloader
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strings"
+)
+
+// Scryfall set holding the tokens of the drops that don't number them in SLD
+const tokenSetCode = "TSLD"
+
+// Scryfall code of the set the card is numbered in
+func scryfallSetCode(card CardData) string {
+	if card.Set == "" {
+		return "sld"
+	}
+	return strings.ToLower(card.Set)
+}
+
+// Look up the tokens without a number in the token set, returning how many
+// were resolved
+func resolveTokens(ctx context.Context, cards []CardData) int {
+	resolved := 0
+	for i, card := range cards {
+		if !card.Token || card.Number != "" {
+			continue
+		}
+
+		results, err := search(ctx, fmt.Sprintf("set:%s !\"%s\"", tokenSetCode, card.Name))
+		if err != nil || len(results) == 0 {
+			log.Println(card.Name, "token not found in", tokenSetCode)
+			continue
+		}
+
+		cards[i].Set = tokenSetCode
+		cards[i].Number = results[0].Number
+		cards[i].source = sourceScryfall
+		copyIdentifiers(&cards[i], results[0])
+		resolved++
+	}
+	return resolved
+}
+
+// Split the tokens of a drop into a set of their own, for the drops exported
+// with their tokens in a separate file
+func splitTokens(cardSet *CardSet) (*CardSet, *CardSet) {
+	cards := *cardSet
+	cards.Cards = nil
+	tokens := *cardSet
+	tokens.Cards = nil
+	tokens.Title += " Tokens"
+	tokens.Filename += " Tokens"
+
+	for _, card := range cardSet.Cards {
+		if card.Token {
+			tokens.Cards = append(tokens.Cards, card)
+		} else {
+			cards.Cards = append(cards.Cards, card)
+		}
+	}
+	return &cards, &tokens
+}
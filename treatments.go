@@ -0,0 +1,45 @@
+package sldownloader
+
+import (
+	"slices"
+
+	"github.com/BlueMonday/go-scryfall"
+)
+
+// Promo types that don't describe how the card looks, or are reported as a
+// component instead
+var ignoredPromoTypes = []string{"boosterfun", "sldbonus", "thick"}
+
+// Frame effects that belong to the card rather than to its treatment
+var ignoredFrameEffects = []scryfall.FrameEffect{
+	"legendary", "miracle", "nyxtouched", "enchantment", "draft", "devoid",
+	"tombstone", "colorshifted", "companion", "lesson", "spree",
+	"sunmoondfc", "compasslanddfc", "originpwdfc", "mooneldrazidfc",
+	"waxingandwaningmoondfc", "meldtransformdfc", "fandfc", "convertdfc",
+	"upsidedowndfc",
+}
+
+// Treatments of a Scryfall printing, such as textured or showcase, sorted and
+// without duplicates
+func scryfallTreatments(card scryfall.Card) []string {
+	var treatments []string
+	for _, promoType := range card.PromoTypes {
+		if !slices.Contains(ignoredPromoTypes, promoType) {
+			treatments = append(treatments, promoType)
+		}
+	}
+	for _, effect := range card.FrameEffects {
+		if !slices.Contains(ignoredFrameEffects, effect) {
+			treatments = append(treatments, string(effect))
+		}
+	}
+	if card.BorderColor == "borderless" {
+		treatments = append(treatments, "borderless")
+	}
+	if card.FullArt {
+		treatments = append(treatments, "fullart")
+	}
+
+	slices.Sort(treatments)
+	return slices.Compact(treatments)
+}
@@ -0,0 +1,344 @@
+package sldownloader
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+type tuiView int
+
+const (
+	dropsView tuiView = iota
+	cardsView
+)
+
+// Result of a background re-scrape of a drop
+type tuiScrapedMsg struct {
+	index  int
+	result *ScrapeResult
+	err    error
+}
+
+// Result of a background verification of the cards of a drop
+type tuiVerifiedMsg struct {
+	index  int
+	issues [][]string
+}
+
+type tuiModel struct {
+	paths   []string
+	sets    []*CardSet
+	scraper *Scraper
+
+	view tuiView
+	drop int
+	card int
+
+	// Verification results of the cards of each drop, when available
+	issues map[int][][]string
+
+	editing bool
+	input   string
+
+	busy   bool
+	status string
+	height int
+}
+
+func (m tuiModel) Init() tea.Cmd {
+	return nil
+}
+
+// Write the drop back to the file it was loaded from
+func (m tuiModel) save(index int) error {
+	_, err := dumpCards(m.sets[index], strings.TrimSuffix(m.paths[index], ".txt"), outputFormats["txt"])
+	return err
+}
+
+func (m tuiModel) rescrape(index int) tea.Cmd {
+	link := m.sets[index].Link
+	return func() tea.Msg {
+		result, err := m.scraper.scrapeProduct(link, "")
+		return tuiScrapedMsg{index: index, result: result, err: err}
+	}
+}
+
+func (m tuiModel) verify(index int) tea.Cmd {
+	cards := m.sets[index].Cards
+	return func() tea.Msg {
+		issues := make([][]string, len(cards))
+		for i, card := range cards {
+			issues[i] = verifyCard(context.Background(), card)
+		}
+		return tuiVerifiedMsg{index: index, issues: issues}
+	}
+}
+
+func (m tuiModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.height = msg.Height
+		return m, nil
+
+	case tuiScrapedMsg:
+		m.busy = false
+		if msg.err != nil {
+			m.status = "Re-scrape failed: " + msg.err.Error()
+			return m, nil
+		}
+		cardSet := msg.result.CardSet
+		if m.sets[msg.index].ReleaseDate != "" {
+			cardSet.ReleaseDate = m.sets[msg.index].ReleaseDate
+		}
+		m.sets[msg.index] = cardSet
+		delete(m.issues, msg.index)
+		m.card = 0
+		err := m.save(msg.index)
+		if err != nil {
+			m.status = "Unable to save: " + err.Error()
+			return m, nil
+		}
+		m.status = "Re-scraped " + cardSet.Title
+		if len(msg.result.Warnings) > 0 {
+			m.status += fmt.Sprintf(" with %d warnings, the first one being %s", len(msg.result.Warnings), msg.result.Warnings[0])
+		}
+		return m, nil
+
+	case tuiVerifiedMsg:
+		m.busy = false
+		m.issues[msg.index] = msg.issues
+		problems := 0
+		for _, issues := range msg.issues {
+			problems += len(issues)
+		}
+		m.status = fmt.Sprintf("Verified, %d problems found", problems)
+		return m, nil
+
+	case tea.KeyMsg:
+		if m.editing {
+			return m.updateEditing(msg)
+		}
+		return m.updateBrowsing(msg)
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateEditing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.Type {
+	case tea.KeyEsc:
+		m.editing = false
+		m.status = "Edit cancelled"
+	case tea.KeyEnter:
+		m.editing = false
+		m.sets[m.drop].Cards[m.card].Number = strings.TrimSpace(m.input)
+		if m.issues[m.drop] != nil {
+			m.issues[m.drop][m.card] = nil
+		}
+		err := m.save(m.drop)
+		if err != nil {
+			m.status = "Unable to save: " + err.Error()
+		} else {
+			m.status = "Saved " + m.paths[m.drop]
+		}
+	case tea.KeyBackspace:
+		if len(m.input) > 0 {
+			m.input = m.input[:len(m.input)-1]
+		}
+	case tea.KeyRunes:
+		m.input += string(msg.Runes)
+	}
+	return m, nil
+}
+
+func (m tuiModel) updateBrowsing(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	switch msg.String() {
+	case "q", "ctrl+c":
+		return m, tea.Quit
+
+	case "up", "k":
+		if m.view == dropsView && m.drop > 0 {
+			m.drop--
+		} else if m.view == cardsView && m.card > 0 {
+			m.card--
+		}
+
+	case "down", "j":
+		if m.view == dropsView && m.drop < len(m.sets)-1 {
+			m.drop++
+		} else if m.view == cardsView && m.card < len(m.sets[m.drop].Cards)-1 {
+			m.card++
+		}
+
+	case "enter", "right", "l":
+		if m.view == dropsView && len(m.sets) > 0 {
+			m.view = cardsView
+			m.card = 0
+		}
+
+	case "esc", "left", "h":
+		m.view = dropsView
+
+	case "e":
+		if m.view == cardsView && len(m.sets[m.drop].Cards) > 0 {
+			m.editing = true
+			m.input = m.sets[m.drop].Cards[m.card].Number
+			m.status = ""
+		}
+
+	case "v":
+		if !m.busy && len(m.sets) > 0 {
+			m.busy = true
+			m.status = "Verifying " + m.sets[m.drop].Title + "..."
+			return m, m.verify(m.drop)
+		}
+
+	case "r":
+		if m.busy || len(m.sets) == 0 {
+			break
+		}
+		if m.sets[m.drop].Link == "" {
+			m.status = "No source link to re-scrape from"
+			break
+		}
+		m.busy = true
+		m.status = "Re-scraping " + m.sets[m.drop].Title + "..."
+		return m, m.rescrape(m.drop)
+	}
+	return m, nil
+}
+
+// Return the range of a list of n elements to display around the cursor
+func visibleRange(cursor, n, height int) (int, int) {
+	if height <= 0 || n <= height {
+		return 0, n
+	}
+	start := max(0, cursor-height/2)
+	end := min(n, start+height)
+	return end - height, end
+}
+
+func (m tuiModel) View() string {
+	var b strings.Builder
+
+	// Leave room for the header and the footer
+	rows := m.height - 4
+
+	switch m.view {
+	case dropsView:
+		fmt.Fprintf(&b, "%d drops\n\n", len(m.sets))
+		start, end := visibleRange(m.drop, len(m.sets), rows)
+		for i := start; i < end; i++ {
+			cardSet := m.sets[i]
+			missing := 0
+			for _, card := range cardSet.Cards {
+				if card.Number == "" {
+					missing++
+				}
+			}
+
+			cursor := "  "
+			if i == m.drop {
+				cursor = "> "
+			}
+			line := fmt.Sprintf("%s%-10s %s (%d cards", cursor, cardSet.ReleaseDate, cardSet.Title, len(cardSet.Cards))
+			if missing > 0 {
+				line += fmt.Sprintf(", %d missing", missing)
+			}
+			fmt.Fprintln(&b, line+")")
+		}
+
+	case cardsView:
+		cardSet := m.sets[m.drop]
+		fmt.Fprintf(&b, "%s\n%s\n", cardSet.Title, cardSet.Link)
+		start, end := visibleRange(m.card, len(cardSet.Cards), rows)
+		for i := start; i < end; i++ {
+			card := cardSet.Cards[i]
+
+			status := "ok"
+			if card.Number == "" {
+				status = "missing"
+			} else if m.issues[m.drop] == nil {
+				status = "unverified"
+			} else if len(m.issues[m.drop][i]) > 0 {
+				status = strings.Join(m.issues[m.drop][i], ", ")
+			}
+
+			cursor := "  "
+			if i == m.card {
+				cursor = "> "
+			}
+			line := cursor + formatCard(card)
+			if m.editing && i == m.card {
+				line = fmt.Sprintf("%snumber: %s_", cursor, m.input)
+			}
+			fmt.Fprintf(&b, "%-60s %s\n", line, status)
+		}
+	}
+
+	help := "enter: open  v: verify  r: re-scrape  q: quit"
+	if m.view == cardsView {
+		help = "esc: back  e: edit number  v: verify  r: re-scrape  q: quit"
+	}
+	if m.editing {
+		help = "enter: save  esc: cancel"
+	}
+	fmt.Fprintf(&b, "\n%s\n%s", m.status, help)
+
+	return b.String()
+}
+
+func runTUI(args []string) int {
+	fs := flag.NewFlagSet("tui", flag.ExitOnError)
+	doOCROpt := fs.Bool("ocr", false, "Use OCR when re-scraping drops")
+	headersCacheOpt := fs.String("headers-cache", defaultHeadersCachePath(), "File where the Scryfall set headers are persisted between runs, empty to disable")
+	headersTTLOpt := fs.Duration("headers-ttl", defaultHeadersTTL, "How long the persisted Scryfall set headers are considered fresh")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader tui [-ocr] dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	paths := parseInterspersed(fs, args)
+
+	if len(paths) == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(paths, outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	model := tuiModel{
+		scraper: newScraper(*headersCacheOpt, *headersTTLOpt),
+		issues:  map[int][][]string{},
+	}
+	model.scraper.OCR = *doOCROpt
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			continue
+		}
+		model.paths = append(model.paths, path)
+		model.sets = append(model.sets, cardSet)
+	}
+
+	// Logs would garble the screen, the status line reports what matters
+	log.SetOutput(io.Discard)
+	defer log.SetOutput(os.Stderr)
+
+	_, err = tea.NewProgram(model, tea.WithAltScreen()).Run()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	return 0
+}
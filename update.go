@@ -0,0 +1,216 @@
+package sldownloader
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const (
+	updateRepo          = "mtgban/sldownloader"
+	checksumsAssetName  = "checksums.txt"
+	maxUpdateBinarySize = 128 << 20
+)
+
+// Set at build time with -ldflags "-X github.com/mtgban/sldownloader.version=v1.2.3"
+var version = "dev"
+
+type githubLatestRelease struct {
+	TagName string `json:"tag_name"`
+	Assets  []struct {
+		Name string `json:"name"`
+		URL  string `json:"browser_download_url"`
+	} `json:"assets"`
+}
+
+// Name of the release asset built for the running platform
+func updateAssetName() string {
+	name := fmt.Sprintf("sldownloader_%s_%s", runtime.GOOS, runtime.GOARCH)
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+func downloadUpdateAsset(ctx context.Context, link string, limit int64) ([]byte, error) {
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, link, nil)
+	if err != nil {
+		return nil, err
+	}
+	if strings.HasPrefix(link, githubAPIURL) {
+		req.Header.Set("Accept", "application/vnd.github+json")
+	}
+	// Not required, but raises the API rate limit
+	token := os.Getenv("GITHUB_TOKEN")
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%s: %s", req.URL.Path, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, limit+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > limit {
+		return nil, errResponseTooLarge
+	}
+	return data, nil
+}
+
+// Look up the expected hash of an asset in a sha256sum-style file
+func findChecksum(checksums []byte, name string) (string, error) {
+	scanner := bufio.NewScanner(bytes.NewReader(checksums))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && strings.TrimPrefix(fields[1], "*") == name {
+			return fields[0], nil
+		}
+	}
+	return "", fmt.Errorf("no checksum for %s", name)
+}
+
+// Atomically swap the running executable with the new binary
+func replaceExecutable(data []byte) error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	exe, err = filepath.EvalSymlinks(exe)
+	if err != nil {
+		return err
+	}
+
+	// Create the file next to the executable so that the rename is atomic
+	tmp, err := os.CreateTemp(filepath.Dir(exe), ".sldownloader-update-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err == nil {
+		err = tmp.Chmod(0755)
+	}
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	// A running executable cannot be replaced on Windows, but it can be moved
+	if runtime.GOOS == "windows" {
+		old := exe + ".old"
+		os.Remove(old)
+		err = os.Rename(exe, old)
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Rename(tmp.Name(), exe)
+}
+
+func runUpdate(args []string) int {
+	fs := flag.NewFlagSet("update", flag.ExitOnError)
+	checkOpt := fs.Bool("check", false, "Only report whether a newer version is available")
+	forceOpt := fs.Bool("force", false, "Install the latest release even if it is not newer, or this is a development build")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader update [-check] [-force]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	ctx := context.Background()
+
+	data, err := downloadUpdateAsset(ctx, githubAPIURL+updateRepo+"/releases/latest", maxProductsResponseSize)
+	if err != nil {
+		log.Println("Unable to check for updates:", err)
+		return 1
+	}
+	var release githubLatestRelease
+	err = json.Unmarshal(data, &release)
+	if err != nil {
+		log.Println("Unable to check for updates:", err)
+		return 1
+	}
+
+	if release.TagName == version && !*forceOpt {
+		log.Println("Already up to date,", version)
+		return 0
+	}
+	log.Println("Current version is", version+", latest release is", release.TagName)
+	if *checkOpt {
+		return 0
+	}
+	if version == "dev" && !*forceOpt {
+		log.Println("This is a development build, use -force to replace it")
+		return 1
+	}
+
+	assets := map[string]string{}
+	for _, asset := range release.Assets {
+		assets[asset.Name] = asset.URL
+	}
+	name := updateAssetName()
+	if assets[name] == "" || assets[checksumsAssetName] == "" {
+		log.Println("Release", release.TagName, "has no", name, "binary or checksums")
+		return 1
+	}
+
+	checksums, err := downloadUpdateAsset(ctx, assets[checksumsAssetName], maxProductsResponseSize)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	want, err := findChecksum(checksums, name)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	binary, err := downloadUpdateAsset(ctx, assets[name], maxUpdateBinarySize)
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+	sum := sha256.Sum256(binary)
+	if !strings.EqualFold(hex.EncodeToString(sum[:]), want) {
+		log.Println("Checksum mismatch, not updating")
+		return 1
+	}
+
+	err = replaceExecutable(binary)
+	if err != nil {
+		log.Println("Unable to replace the executable:", err)
+		return 1
+	}
+
+	log.Println("Updated to", release.TagName)
+	return 0
+}
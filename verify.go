@@ -0,0 +1,106 @@
+package sldownloader
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Re-resolve every card of previously generated files against Scryfall and
+// report the ones that don't match anymore
+func runVerify(args []string) int {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: sldownloader verify dir/ [file.txt...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() == 0 {
+		fs.Usage()
+		return 1
+	}
+
+	files, err := listOutputFiles(fs.Args(), outputFormats["txt"])
+	if err != nil {
+		log.Println(err)
+		return 1
+	}
+
+	problems := 0
+	for _, path := range files {
+		cardSet, err := loadCardSet(path)
+		if err != nil {
+			log.Println(err)
+			problems++
+			continue
+		}
+
+		for _, card := range cardSet.Cards {
+			for _, problem := range verifyCard(context.Background(), card) {
+				fmt.Fprintf(os.Stdout, "%s: %s - %s\n", path, formatCard(card), problem)
+				problems++
+			}
+		}
+	}
+
+	log.Println("Verified", len(files), "files,", problems, "problems found")
+	if problems > 0 {
+		return 1
+	}
+	return 0
+}
+
+// Return a list of differences between the card and its Scryfall printing
+func verifyCard(ctx context.Context, card CardData) []string {
+	if card.Number == "" {
+		return []string{"missing collector number"}
+	}
+
+	// The face suffix is not part of the Scryfall collector number
+	number := baseNumber(card.Number)
+	results, err := searchCards(ctx, fmt.Sprintf("e:%s cn:\"%s\"", scryfallSetCode(card), number))
+	if err != nil || len(results) == 0 {
+		return []string{"collector number not found on Scryfall"}
+	}
+	printing := results[0]
+
+	var problems []string
+
+	name := strings.Split(printing.Name, " // ")[0]
+	if strings.HasSuffix(card.Number, "b") && len(printing.CardFaces) > 1 {
+		name = printing.CardFaces[1].Name
+	}
+	if name != card.Name {
+		problems = append(problems, fmt.Sprintf("name differs from Scryfall (%s)", name))
+	}
+
+	if isDoubleFaced(printing) != (number != card.Number) {
+		problems = append(problems, "face suffix differs from Scryfall")
+	}
+
+	var finishes []string
+	for _, finish := range printing.Finishes {
+		finishes = append(finishes, string(finish))
+	}
+	switch {
+	case card.Etched:
+		if !slices.Contains(finishes, "etched") {
+			problems = append(problems, "not etched on Scryfall")
+		}
+	case card.Foil:
+		if !slices.Contains(finishes, "foil") {
+			problems = append(problems, "not foil on Scryfall")
+		}
+	default:
+		if !slices.Contains(finishes, "nonfoil") {
+			problems = append(problems, "not nonfoil on Scryfall")
+		}
+	}
+
+	return problems
+}
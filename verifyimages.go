@@ -0,0 +1,105 @@
+package sldownloader
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"os"
+	"slices"
+	"time"
+)
+
+// A gallery image whose printed number doesn't belong to the card listed at
+// its position
+type imageMismatch struct {
+	Title    string `json:"title"`
+	Link     string `json:"link"`
+	Image    string `json:"image"`
+	Position int    `json:"position"`
+	// Card listed by the store at the position of the image
+	Listed string `json:"listed"`
+	// Number read from the image, and the card exported with it if any
+	Number   string `json:"number"`
+	Numbered string `json:"numbered,omitempty"`
+}
+
+// OCR the gallery fronts and compare their numbers with the ones found on
+// Scryfall, without changing any of them
+func (ps *productScrape) verifyImages() {
+	cards := ps.cards
+	timings := ps.cardSet.timings
+
+	pos := 0
+	for _, s := range galleryFronts(ps.doc, len(cards)) {
+		if pos >= len(ps.listed) {
+			break
+		}
+		i := pos
+		pos++
+
+		// Only the numbers found on Scryfall are in doubt
+		listed := slices.IndexFunc(cards, func(card CardData) bool {
+			return card.Name == ps.listed[i]
+		})
+		if listed < 0 || cards[listed].source != sourceScryfall {
+			continue
+		}
+
+		imgLink, found := galleryImageLink(s)
+		if !found {
+			continue
+		}
+		start := time.Now()
+		num, err := ps.scraper.getNumberFromLink(imgLink, ps.language)
+		timings.observe("ocr", start)
+		if errors.Is(err, errPackagingImage) {
+			pos--
+			continue
+		}
+		if err != nil || num == "" {
+			log.Println(imgLink, "could not be verified:", err)
+			continue
+		}
+
+		owner := slices.IndexFunc(cards, func(card CardData) bool {
+			n, found := collectorNumber(card.Number)
+			m, _ := collectorNumber(num)
+			return found && n == m
+		})
+		if owner >= 0 && cards[owner].Name == ps.listed[i] {
+			continue
+		}
+
+		mismatch := imageMismatch{
+			Title:    ps.cardSet.Title,
+			Link:     ps.cardSet.Link,
+			Image:    imgLink,
+			Position: i + 1,
+			Listed:   ps.listed[i],
+			Number:   num,
+		}
+		if owner >= 0 {
+			mismatch.Numbered = cards[owner].Name
+			ps.warn(warnImageMismatch, "Image %d shows %s, which is %s on Scryfall, but the store lists %s there",
+				i+1, num, mismatch.Numbered, mismatch.Listed)
+		} else {
+			ps.warn(warnImageMismatch, "Image %d shows %s, which is not part of the drop on Scryfall, the store lists %s there",
+				i+1, num, mismatch.Listed)
+		}
+		ps.mismatches = append(ps.mismatches, mismatch)
+	}
+}
+
+// Write the mismatches found in the results as a JSON array
+func writeMismatchReport(path string, results []*ScrapeResult) error {
+	mismatches := []imageMismatch{}
+	for _, result := range results {
+		mismatches = append(mismatches, result.Mismatches...)
+	}
+	data, err := json.MarshalIndent(mismatches, "", "  ")
+	if err != nil {
+		return err
+	}
+	log.Println(len(mismatches), "gallery images don't match their numbers, see", path)
+	return os.WriteFile(path, data, 0644)
+}
@@ -0,0 +1,78 @@
+package sldownloader
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Kinds of data-quality problems that don't prevent a drop from being scraped
+const (
+	warnSkippedLine = "skipped_line"
+	warnMismatch    = "mismatch"
+	warnUnmatched   = "unmatched"
+	warnGallery     = "gallery"
+	warnOCR         = "ocr"
+	warnValidation  = "validation"
+	warnBackfill    = "backfill"
+	warnMissing     = "missing_numbers"
+	warnReleaseDate = "release_date"
+
+	warnImageMismatch = "image_mismatch"
+)
+
+type ScrapeWarning struct {
+	Kind    string `json:"kind"`
+	Message string `json:"message"`
+}
+
+func (warning ScrapeWarning) String() string {
+	return warning.Kind + ": " + warning.Message
+}
+
+// Outcome of scraping a product, the warnings tell how much the numbers of
+// the cards can be trusted
+type ScrapeResult struct {
+	CardSet  *CardSet        `json:"card_set"`
+	Warnings []ScrapeWarning `json:"warnings,omitempty"`
+	// Gallery images contradicting the numbers, when verified
+	Mismatches []imageMismatch `json:"mismatches,omitempty"`
+}
+
+// Log a warning and record it in the result of the scrape
+func (ps *productScrape) warn(kind, format string, args ...any) {
+	message := fmt.Sprintf(format, args...)
+	log.Println(message)
+	ps.warnings = append(ps.warnings, ScrapeWarning{Kind: kind, Message: message})
+}
+
+func (ps *productScrape) result() *ScrapeResult {
+	return &ScrapeResult{
+		CardSet:    &ps.cardSet,
+		Warnings:   ps.warnings,
+		Mismatches: ps.mismatches,
+	}
+}
+
+// Count the warnings of the results by kind, such as "2 ocr, 1 unmatched"
+func summarizeWarnings(results []*ScrapeResult) string {
+	counts := map[string]int{}
+	for _, result := range results {
+		for _, warning := range result.Warnings {
+			counts[warning.Kind]++
+		}
+	}
+
+	var kinds []string
+	for kind := range counts {
+		kinds = append(kinds, kind)
+	}
+	slices.Sort(kinds)
+
+	var out []string
+	for _, kind := range kinds {
+		out = append(out, fmt.Sprintf("%d %s", counts[kind], kind))
+	}
+	return strings.Join(out, ", ")
+}
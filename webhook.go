@@ -0,0 +1,79 @@
+package sldownloader
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const webhookSignatureHeader = "X-Sldownloader-Signature"
+
+// Information about the run that produced an event
+type runMetadata struct {
+	StartedAt time.Time `json:"started_at"`
+	Hostname  string    `json:"hostname,omitempty"`
+}
+
+type webhookPayload struct {
+	dropEvent
+	Run runMetadata `json:"run"`
+}
+
+// POST the full card set to an URL after each export, signing the body with
+// HMAC-SHA256 when a secret is set
+type webhookNotifier struct {
+	URL    string
+	Secret string
+	Run    runMetadata
+
+	// The run may restart while the dashboard notifies a re-scrape
+	mu sync.Mutex
+}
+
+func (w *webhookNotifier) StartRun(at time.Time) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	w.Run.StartedAt = at
+}
+
+func (w *webhookNotifier) NotifyDrop(cardSet *CardSet) error {
+	w.mu.Lock()
+	run := w.Run
+	w.mu.Unlock()
+	body, err := json.Marshal(webhookPayload{
+		dropEvent: newDropEvent(cardSet),
+		Run:       run,
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := retryablehttp.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if w.Secret != "" {
+		req.Header.Set(webhookSignatureHeader, "sha256="+signPayload(w.Secret, body))
+	}
+
+	return doNotificationRequest(req)
+}
+
+// Only successful exports are delivered
+func (w *webhookNotifier) NotifyFailure(link string, err error) error {
+	return nil
+}
+
+func signPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,355 @@
+package sldownloader
+
+import (
+	"cmp"
+	"crypto/subtle"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"mime"
+	"net"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+//go:embed webui
+var webuiFiles embed.FS
+
+// How many re-scrapes are remembered for the dashboard
+const maxRescrapeJobs = 20
+
+// Summary of an exported drop, for the list of recent drops
+type dropSummary struct {
+	File        string `json:"file"`
+	Title       string `json:"title"`
+	ReleaseDate string `json:"release_date,omitempty"`
+	Category    string `json:"category,omitempty"`
+	Status      string `json:"status,omitempty"`
+	Link        string `json:"link"`
+	Cards       int    `json:"cards"`
+	Numbered    int    `json:"numbered"`
+}
+
+// A re-scrape requested from the dashboard
+type rescrapeJob struct {
+	Link     string    `json:"link"`
+	State    string    `json:"state"`
+	Error    string    `json:"error,omitempty"`
+	Queued   time.Time `json:"queued"`
+	Finished time.Time `json:"finished"`
+}
+
+// Web dashboard of a scheduled crawler, showing the exported drops and the
+// failures, from which any product can be scraped again
+type webUI struct {
+	opts crawlOptions
+	// Bearer token required to queue re-scrapes, if set
+	token string
+
+	mu   sync.Mutex
+	jobs []*rescrapeJob
+	// Re-scrapes run one at a time, in the order they were requested
+	queue chan *rescrapeJob
+}
+
+// Serve the dashboard on addr, on the loopback interface unless it names a
+// host, in which case the re-scrapes need the token
+func serveWebUI(addr, token string, opts crawlOptions) error {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return err
+	}
+	if host == "" {
+		addr = net.JoinHostPort("localhost", port)
+	} else if !isLoopback(host) && token == "" {
+		return fmt.Errorf("the dashboard on %s can be reached from other hosts, set UI_TOKEN", addr)
+	}
+
+	ui := &webUI{
+		opts:  opts,
+		token: token,
+		queue: make(chan *rescrapeJob, maxRescrapeJobs),
+	}
+	go ui.rescrapeLoop()
+
+	// Never fails, the directory is embedded
+	static, _ := fs.Sub(webuiFiles, "webui")
+
+	mux := http.NewServeMux()
+	mux.Handle("GET /", http.FileServerFS(static))
+	mux.HandleFunc("GET /api/drops", ui.handleDrops)
+	mux.HandleFunc("GET /api/drops/{file...}", ui.handleDrop)
+	mux.HandleFunc("GET /api/failures", ui.handleFailures)
+	mux.HandleFunc("GET /api/rescrapes", ui.handleRescrapes)
+	mux.HandleFunc("POST /api/rescrapes", ui.handleRescrape)
+
+	go func() {
+		log.Println("Serving the dashboard on", addr)
+		err := http.ListenAndServe(addr, mux)
+		if err != nil {
+			log.Println("dashboard server:", err)
+		}
+	}()
+	return nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// Whether the link is a product page of the store, the only pages that may
+// be scraped on request
+func isStoreProductLink(link string) bool {
+	u, err := url.Parse(link)
+	if err != nil {
+		return false
+	}
+	store, _ := url.Parse(storefrontURL)
+	return u.Scheme == store.Scheme && u.Host == store.Host && u.User == nil &&
+		strings.Contains(u.Path, "/product/")
+}
+
+// Whether the request may queue a re-scrape: it must carry the token when
+// there is one, or else come from this host, and it must be sent as JSON,
+// which browsers don't do across sites without asking the server first
+func (ui *webUI) authorized(r *http.Request) bool {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/json" {
+		return false
+	}
+	if ui.token != "" {
+		given, found := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		return found && subtle.ConstantTimeCompare([]byte(given), []byte(ui.token)) == 1
+	}
+	// Pages of other sites resolving their name to this host don't have it
+	host, _, err := net.SplitHostPort(r.Host)
+	if err != nil {
+		host = r.Host
+	}
+	return isLoopback(host)
+}
+
+func writeJSONResponse(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	err := json.NewEncoder(w).Encode(v)
+	if err != nil {
+		log.Println("dashboard:", err)
+	}
+}
+
+func writeJSONError(w http.ResponseWriter, status int, err error) {
+	writeJSONResponse(w, status, map[string]string{"error": err.Error()})
+}
+
+// Every exported drop, the most recent first, limited by the limit parameter
+func (ui *webUI) handleDrops(w http.ResponseWriter, r *http.Request) {
+	files, err := listOutputFiles([]string{outputDir}, ui.opts.Format)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	drops := []dropSummary{}
+	for _, file := range files {
+		cardSet, err := loadCardSet(file)
+		if err != nil {
+			continue
+		}
+		numbered := 0
+		for _, card := range cardSet.Cards {
+			if card.Number != "" {
+				numbered++
+			}
+		}
+		drops = append(drops, dropSummary{
+			File:        file,
+			Title:       cardSet.Title,
+			ReleaseDate: cardSet.ReleaseDate,
+			Category:    cardSet.Category,
+			Status:      cardSet.Status,
+			Link:        cardSet.Link,
+			Cards:       len(cardSet.Cards),
+			Numbered:    numbered,
+		})
+	}
+	slices.SortStableFunc(drops, func(a, b dropSummary) int {
+		return cmp.Compare(b.ReleaseDate, a.ReleaseDate)
+	})
+
+	limit, err := strconv.Atoi(r.URL.Query().Get("limit"))
+	if err == nil && limit > 0 && limit < len(drops) {
+		drops = drops[:limit]
+	}
+	writeJSONResponse(w, http.StatusOK, drops)
+}
+
+// The cards of an exported drop, by file name
+func (ui *webUI) handleDrop(w http.ResponseWriter, r *http.Request) {
+	files, err := listOutputFiles([]string{outputDir}, ui.opts.Format)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	// Only the exported files can be read
+	file := r.PathValue("file")
+	if !slices.Contains(files, file) {
+		http.NotFound(w, r)
+		return
+	}
+
+	cardSet, err := loadCardSet(file)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, cardSet)
+}
+
+func (ui *webUI) handleFailures(w http.ResponseWriter, r *http.Request) {
+	if ui.opts.Failures == "" {
+		writeJSONResponse(w, http.StatusOK, []failedProduct{})
+		return
+	}
+	queue, err := loadFailureQueue(ui.opts.Failures)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSONResponse(w, http.StatusOK, append([]failedProduct{}, queue.Products...))
+}
+
+func (ui *webUI) handleRescrapes(w http.ResponseWriter, r *http.Request) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	writeJSONResponse(w, http.StatusOK, append([]*rescrapeJob{}, ui.jobs...))
+}
+
+// Queue a re-scrape of the product at the link of the posted JSON object
+func (ui *webUI) handleRescrape(w http.ResponseWriter, r *http.Request) {
+	if !ui.authorized(r) {
+		writeJSONResponse(w, http.StatusUnauthorized, map[string]string{"error": "unauthorized"})
+		return
+	}
+	var request struct {
+		Link string `json:"link"`
+	}
+	err := json.NewDecoder(io.LimitReader(r.Body, 1<<16)).Decode(&request)
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, err)
+		return
+	}
+	link := request.Link
+	if !isStoreProductLink(link) {
+		writeJSONResponse(w, http.StatusBadRequest, map[string]string{"error": "not a product link of " + storefrontURL})
+		return
+	}
+
+	job := &rescrapeJob{Link: link, State: "queued", Queued: time.Now()}
+	select {
+	case ui.queue <- job:
+	default:
+		writeJSONResponse(w, http.StatusServiceUnavailable, map[string]string{"error": "too many re-scrapes queued"})
+		return
+	}
+
+	ui.mu.Lock()
+	ui.jobs = append([]*rescrapeJob{job}, ui.jobs...)
+	if len(ui.jobs) > maxRescrapeJobs {
+		ui.jobs = ui.jobs[:maxRescrapeJobs]
+	}
+	ui.mu.Unlock()
+
+	writeJSONResponse(w, http.StatusAccepted, job)
+}
+
+func (ui *webUI) setState(job *rescrapeJob, state string, err error) {
+	ui.mu.Lock()
+	defer ui.mu.Unlock()
+	job.State = state
+	if err != nil {
+		job.Error = err.Error()
+	}
+	if state != "running" {
+		job.Finished = time.Now()
+	}
+}
+
+func (ui *webUI) rescrapeLoop() {
+	for job := range ui.queue {
+		ui.setState(job, "running", nil)
+		err := rescrape(job.Link, ui.opts)
+		if err != nil {
+			log.Println(job.Link, "-", err)
+			ui.setState(job, "failed", err)
+			continue
+		}
+		ui.setState(job, "done", nil)
+	}
+}
+
+// Scrape a product again and export it over its existing file, if any,
+// keeping the release date the store gave it
+func rescrape(link string, opts crawlOptions) error {
+	release, err := lockOutput(opts.LockWait)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	result, err := opts.Scraper.scrapeProduct(link, "")
+	if err != nil {
+		opts.notifyFailure(link, err)
+		return err
+	}
+	cardSet := result.CardSet
+
+	files, err := listOutputFiles([]string{outputDir}, opts.Format)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		existing, err := loadCardSet(file)
+		if err != nil || productKey(existing.Link) != productKey(link) {
+			continue
+		}
+		cardSet.Filename = existing.Filename
+		if existing.ReleaseDate != "" {
+			cardSet.ReleaseDate = existing.ReleaseDate
+		}
+		break
+	}
+
+	written, err := exportCardSet(cardSet, cardSet.Filename, opts)
+	if err != nil {
+		opts.notifyFailure(link, err)
+		return err
+	}
+	// The failure stays until the changes are accepted
+	if written == fileHeld {
+		return errHeld
+	}
+	opts.notifyDrop(cardSet)
+
+	if opts.Failures != "" {
+		queue, err := loadFailureQueue(opts.Failures)
+		if err != nil {
+			return err
+		}
+		queue.Remove(link)
+		return queue.Save()
+	}
+	return nil
+}
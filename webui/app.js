@@ -0,0 +1,129 @@
+"use strict";
+
+function cell(row, text) {
+  const td = document.createElement("td");
+  td.textContent = text ?? "";
+  row.appendChild(td);
+  return td;
+}
+
+function button(row, label, onclick) {
+  const b = document.createElement("button");
+  b.textContent = label;
+  b.onclick = onclick;
+  cell(row, "").appendChild(b);
+}
+
+async function getJSON(path) {
+  const resp = await fetch(path);
+  if (!resp.ok) {
+    throw new Error(path + ": " + resp.status);
+  }
+  return resp.json();
+}
+
+function fill(id, items, render) {
+  const body = document.querySelector("#" + id + " tbody");
+  body.replaceChildren();
+  for (const item of items) {
+    const row = document.createElement("tr");
+    render(row, item);
+    body.appendChild(row);
+  }
+}
+
+async function rescrape(link) {
+  const post = () => fetch("api/rescrapes", {
+    method: "POST",
+    headers: {
+      "Content-Type": "application/json",
+      "Authorization": "Bearer " + (localStorage.getItem("token") ?? ""),
+    },
+    body: JSON.stringify({ link }),
+  });
+  let resp = await post();
+  if (resp.status === 401) {
+    const token = prompt("Token (UI_TOKEN) of the dashboard");
+    if (token !== null) {
+      localStorage.setItem("token", token);
+      resp = await post();
+    }
+  }
+  if (!resp.ok) {
+    alert((await resp.json()).error);
+  }
+  loadRescrapes();
+}
+
+async function showDrop(file) {
+  const drop = await getJSON("api/drops/" + file.split("/").map(encodeURIComponent).join("/"));
+  document.getElementById("drop-title").textContent = drop.title;
+  const link = document.getElementById("drop-link");
+  link.href = drop.link;
+  link.textContent = drop.link;
+  fill("cards", drop.cards, (row, card) => {
+    if (!card.number) {
+      row.className = "missing";
+    }
+    cell(row, card.number || "missing");
+    cell(row, card.name);
+    cell(row, card.set);
+    cell(row, card.etched ? "etched" : card.foil ? "foil" : "");
+    cell(row, card.number_source);
+    cell(row, (card.flags || []).join(", "));
+  });
+  document.getElementById("drop").hidden = false;
+}
+
+async function loadDrops() {
+  const drops = await getJSON("api/drops?limit=50");
+  fill("drops", drops, (row, drop) => {
+    cell(row, drop.release_date);
+    const title = cell(row, "");
+    const a = document.createElement("a");
+    a.href = "#";
+    a.textContent = drop.title;
+    a.onclick = (e) => {
+      e.preventDefault();
+      showDrop(drop.file);
+    };
+    title.appendChild(a);
+    cell(row, drop.status);
+    cell(row, drop.numbered + "/" + drop.cards);
+    button(row, "Re-scrape", () => rescrape(drop.link));
+  });
+}
+
+async function loadFailures() {
+  const failures = await getJSON("api/failures");
+  fill("failures", failures, (row, failure) => {
+    row.className = "failed";
+    cell(row, failure.title || failure.link);
+    cell(row, failure.class);
+    cell(row, failure.error);
+    cell(row, failure.attempts);
+    cell(row, failure.next_attempt && new Date(failure.next_attempt).toLocaleString());
+    button(row, "Re-scrape", () => rescrape(failure.link));
+  });
+}
+
+async function loadRescrapes() {
+  const jobs = await getJSON("api/rescrapes");
+  fill("rescrapes", jobs, (row, job) => {
+    if (job.state === "failed") {
+      row.className = "failed";
+    }
+    cell(row, job.link);
+    cell(row, job.state);
+    cell(row, job.error);
+  });
+}
+
+loadDrops();
+loadFailures();
+loadRescrapes();
+// Follow the progress of the re-scrapes and of the scheduled crawls
+setInterval(() => {
+  loadFailures();
+  loadRescrapes();
+}, 10000);
@@ -0,0 +1,130 @@
+package sldownloader
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"github.com/PuerkitoBio/goquery"
+	"github.com/hashicorp/go-retryablehttp"
+)
+
+const mtgWikiAPI = "https://mtg.wiki/api.php"
+
+var errWikiPageNotFound = errors.New("no mtg.wiki page found")
+
+// Collector numbers as written in the wiki tables, optionally prefixed
+var wikiNumberRE = regexp.MustCompile(`^(?:SLD\s*|#)?0*(\d+[a-z★]?)$`)
+
+func mtgWikiQuery(ctx context.Context, params url.Values, out any) error {
+	params.Set("format", "json")
+	req, err := retryablehttp.NewRequestWithContext(ctx, http.MethodGet, mtgWikiAPI+"?"+params.Encode(), nil)
+	if err != nil {
+		return err
+	}
+
+	retryClient := newRetryClient()
+
+	resp, err := retryClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("mtg.wiki: %s", resp.Status)
+	}
+	return json.NewDecoder(io.LimitReader(resp.Body, maxProductsResponseSize)).Decode(out)
+}
+
+// Find the wiki page of the drop and return the collector numbers listed in
+// its tables, by lowercase card name
+func wikiNumbers(ctx context.Context, title string) (map[string]string, error) {
+	var search struct {
+		Query struct {
+			Search []struct {
+				Title string `json:"title"`
+			} `json:"search"`
+		} `json:"query"`
+	}
+	err := mtgWikiQuery(ctx, url.Values{
+		"action":   {"query"},
+		"list":     {"search"},
+		"srsearch": {"Secret Lair " + title},
+		"srlimit":  {"1"},
+	}, &search)
+	if err != nil {
+		return nil, err
+	}
+	if len(search.Query.Search) == 0 {
+		return nil, errWikiPageNotFound
+	}
+
+	var parse struct {
+		Parse struct {
+			Text struct {
+				HTML string `json:"*"`
+			} `json:"text"`
+		} `json:"parse"`
+	}
+	err = mtgWikiQuery(ctx, url.Values{
+		"action": {"parse"},
+		"page":   {search.Query.Search[0].Title},
+		"prop":   {"text"},
+	}, &parse)
+	if err != nil {
+		return nil, err
+	}
+
+	doc, err := goquery.NewDocumentFromReader(strings.NewReader(parse.Parse.Text.HTML))
+	if err != nil {
+		return nil, err
+	}
+
+	// Rows listing a card have a cell with its number and one with its name
+	numbers := map[string]string{}
+	doc.Find(`table.wikitable tr`).Each(func(_ int, row *goquery.Selection) {
+		var number, name string
+		row.Find("td").Each(func(_ int, cell *goquery.Selection) {
+			text := strings.TrimSpace(cell.Text())
+			match := wikiNumberRE.FindStringSubmatch(text)
+			if match != nil && number == "" {
+				number = match[1]
+			} else if match == nil && name == "" && text != "" {
+				name = text
+			}
+		})
+		if number != "" && name != "" {
+			numbers[strings.ToLower(name)] = number
+		}
+	})
+	return numbers, nil
+}
+
+// Compare the numbers of the drop with the ones of mtg.wiki, returning a
+// description of every disagreement
+func crossCheckWiki(ctx context.Context, cardSet *CardSet) ([]string, error) {
+	numbers, err := wikiNumbers(ctx, headerTitle(cardSet.Title))
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	for _, card := range cardSet.Cards {
+		number, found := numbers[strings.ToLower(card.Name)]
+		if !found {
+			problems = append(problems, fmt.Sprintf("%s is not listed on mtg.wiki", card.Name))
+			continue
+		}
+		if strings.TrimLeft(card.Number, "0") != number {
+			problems = append(problems, fmt.Sprintf("%s is number %q on mtg.wiki, but %q here", card.Name, number, card.Number))
+		}
+	}
+	return problems, nil
+}
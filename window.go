@@ -0,0 +1,217 @@
+package sldownloader
+
+import (
+	"cmp"
+	"fmt"
+	"log"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/lithammer/fuzzysearch/fuzzy"
+)
+
+// Numeric part of a collector number, such as 1234 for 1234a
+func collectorNumber(number string) (int, bool) {
+	end := strings.IndexFunc(number, func(r rune) bool {
+		return r < '0' || r > '9'
+	})
+	if end < 0 {
+		end = len(number)
+	}
+	n, err := strconv.Atoi(number[:end])
+	return n, err == nil
+}
+
+// Range of the collector numbers of an exported drop, in the SLD set
+type dropNumbers struct {
+	Key         string
+	ReleaseDate string
+	Low, High   int
+}
+
+func numbersOf(cardSet *CardSet) (dropNumbers, bool) {
+	drop := dropNumbers{Key: productKey(cardSet.Link), ReleaseDate: cardSet.ReleaseDate}
+	found := false
+	for _, card := range cardSet.Cards {
+		n, ok := collectorNumber(card.Number)
+		if !ok || (card.Set != "" && !strings.EqualFold(card.Set, "SLD")) {
+			continue
+		}
+		if !found {
+			drop.Low, drop.High = n, n
+		}
+		drop.Low, drop.High = min(drop.Low, n), max(drop.High, n)
+		found = true
+	}
+	return drop, found && drop.ReleaseDate != ""
+}
+
+// Number ranges of the exported drops, read once per crawl from the output
+// directory and kept up to date with the drops exported since
+var exportedNumbers = struct {
+	sync.Mutex
+	// Format of the exported files
+	format outputFormat
+	loaded bool
+	drops  []dropNumbers
+}{format: outputFormats["txt"]}
+
+// Read the drops exported in the format again on the next lookup
+func resetExportedNumbers(format outputFormat) {
+	exportedNumbers.Lock()
+	exportedNumbers.format = format
+	exportedNumbers.loaded = false
+	exportedNumbers.drops = nil
+	exportedNumbers.Unlock()
+}
+
+// Remember the numbers of a drop just exported, replacing its earlier ones
+func recordExportedNumbers(cardSet *CardSet) {
+	exportedNumbers.Lock()
+	defer exportedNumbers.Unlock()
+	// The files are read on the first lookup, with this drop in them
+	if !exportedNumbers.loaded && !exportedNumbers.format.Lossy {
+		return
+	}
+	key := productKey(cardSet.Link)
+	exportedNumbers.drops = slices.DeleteFunc(exportedNumbers.drops, func(drop dropNumbers) bool {
+		return drop.Key == key
+	})
+	drop, found := numbersOf(cardSet)
+	if found {
+		exportedNumbers.drops = append(exportedNumbers.drops, drop)
+	}
+}
+
+// Collector numbers between the last drop released before the date and the
+// first one released after it, among the exported files, zero when unbounded
+func numberWindow(releaseDate, link string) (low, high int) {
+	exportedNumbers.Lock()
+	defer exportedNumbers.Unlock()
+	// Only the drops exported by this crawl are known when the files can't
+	// be read back
+	if !exportedNumbers.loaded && !exportedNumbers.format.Lossy {
+		files, err := listOutputFiles([]string{outputDir}, exportedNumbers.format)
+		if err != nil {
+			log.Println(err)
+			return 0, 0
+		}
+		for _, file := range files {
+			cardSet, err := loadCardSet(file)
+			if err != nil {
+				continue
+			}
+			drop, found := numbersOf(cardSet)
+			if found {
+				exportedNumbers.drops = append(exportedNumbers.drops, drop)
+			}
+		}
+	}
+	exportedNumbers.loaded = true
+
+	key := productKey(link)
+	var before, after string
+	for _, drop := range exportedNumbers.drops {
+		if drop.Key == key {
+			continue
+		}
+		switch {
+		case drop.ReleaseDate < releaseDate && drop.ReleaseDate >= before:
+			if drop.ReleaseDate > before {
+				before, low = drop.ReleaseDate, 0
+			}
+			low = max(low, drop.High)
+		case drop.ReleaseDate > releaseDate && (after == "" || drop.ReleaseDate <= after):
+			if drop.ReleaseDate != after {
+				after, high = drop.ReleaseDate, drop.Low
+			}
+			high = min(high, drop.Low)
+		}
+	}
+	return low, high
+}
+
+// Queries for the printings of a drop missing from the Scryfall headers: the
+// ones released the same day, then the ones numbered between the neighbouring
+// drops
+func windowQueries(releaseDate, link string) []string {
+	if releaseDate == "" {
+		return nil
+	}
+	queries := []string{"e:sld date=" + releaseDate}
+
+	low, high := numberWindow(releaseDate, link)
+	switch {
+	case low > 0 && high > low:
+		queries = append(queries, fmt.Sprintf("e:sld cn>%d cn<%d", low, high))
+	case low > 0 && high == 0:
+		queries = append(queries, fmt.Sprintf("e:sld cn>%d", low))
+	}
+	return queries
+}
+
+// Look for the cards without a number among the printings of the drop's
+// release date or collector number window, by name since the results may
+// belong to other drops, and return how many are still missing
+func (ps *productScrape) windowSearch() int {
+	cardSet := &ps.cardSet
+	missing := 0
+	for _, card := range ps.cards {
+		if card.Number == "" {
+			missing++
+		}
+	}
+
+	for _, query := range windowQueries(cmp.Or(ps.releaseDate, cardSet.ReleaseDate), cardSet.Link) {
+		if missing == 0 {
+			break
+		}
+
+		var results []CardData
+		start := time.Now()
+		err := ps.scraper.retry("scryfall search", func() (err error) {
+			results, err = search(ps.scraper.context(), languageQuery(query, ps.language))
+			return err
+		})
+		cardSet.timings.observe("scryfall", start)
+		if err != nil {
+			log.Println(query, "-", err)
+			continue
+		}
+
+		found := 0
+		for i := range ps.cards {
+			card := &ps.cards[i]
+			if card.Number != "" {
+				continue
+			}
+			for j := range results {
+				if results[j].Number == "" || !windowNameMatches(card.Name, results[j].Name) {
+					continue
+				}
+				card.Number = results[j].Number
+				card.source = sourceScryfall
+				copyIdentifiers(card, results[j])
+				if !strings.EqualFold(card.Name, results[j].Name) {
+					card.flag(flagFuzzyName)
+				}
+				results[j].Number = ""
+				found++
+				break
+			}
+		}
+		if found > 0 {
+			log.Printf("Found %d card numbers with '%s'", found, query)
+		}
+		missing -= found
+	}
+	return missing
+}
+
+func windowNameMatches(name, result string) bool {
+	return strings.EqualFold(name, result) ||
+		fuzzy.MatchNormalizedFold(name, result) || fuzzy.MatchNormalizedFold(result, name)
+}